@@ -2,6 +2,10 @@ package causalcontext
 
 import (
 	"CloudShoppingList/dotcloud"
+	"bytes"
+	"encoding/base64"
+	"encoding/gob"
+	"fmt"
 )
 
 type CausalContext struct {
@@ -27,11 +31,11 @@ func NewCausalContext(cc map[string]int) *CausalContext {
 
 func (ctx *CausalContext) DotIn(dot Pair) bool {
 	key, value := dot.Key, dot.Value
-	_, exists := ctx.Cc[key]
-	if exists {
+	current, exists := ctx.Cc[key]
+	if exists && value <= current {
 		return true
 	}
-	if ctx.Dc.Has(key, value) {
+	if ctx.dotCloud().Has(key, value) {
 		return true
 	}
 	return false
@@ -41,7 +45,7 @@ func (ctx *CausalContext) Compact() *CausalContext {
 	flag := true
 	for flag {
 		flag = false
-		for _, dot := range ctx.Dc.Values() {
+		for _, dot := range ctx.dotCloud().Values() {
 			key, value := dot.Key, dot.Value
 			casual_context_value, exists := ctx.Cc[key]
 			if !exists {
@@ -80,8 +84,7 @@ func (ctx *CausalContext) MakeDot(id string) Pair {
 }
 
 func (ctx *CausalContext) InsertDot(key string, value int, compactNow bool) {
-
-	ctx.Dc.Add(key, value)
+	ctx.dotCloud().Add(key, value)
 	if compactNow {
 		ctx.Compact()
 	}
@@ -115,13 +118,53 @@ func (ctx *CausalContext) Join(other *CausalContext) {
         }
     }
 
+    // Dots outside the compact version vector are kept in the dot cloud
+    for _, dot := range other.dotCloud().Values() {
+        ctx.dotCloud().Add(dot.Key, dot.Value)
+    }
+
     ctx.Compact()
 
 }
 
+// dotCloud returns the dot cloud, creating it when it was lost in decoding.
+func (ctx *CausalContext) dotCloud() *dotcloud.DotCloud {
+	if ctx.Dc == nil {
+		ctx.Dc = dotcloud.NewCustomSet()
+	}
+	return ctx.Dc
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
 	}
 	return b
 }
+
+// ToGOB64 encodes the causal context so that a peer can ask for a delta against it.
+func (ctx *CausalContext) ToGOB64() string {
+	b := bytes.Buffer{}
+	e := gob.NewEncoder(&b)
+	err := e.Encode(ctx)
+	if err != nil {
+		fmt.Println("Error encoding causal context:", err)
+	}
+	return base64.StdEncoding.EncodeToString(b.Bytes())
+}
+
+func FromGOB64(s string) (*CausalContext, error) {
+	ctx := &CausalContext{}
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Cc == nil {
+		ctx.Cc = make(map[string]int)
+	}
+	return ctx, nil
+}
@@ -1,28 +1,46 @@
 package main
 
 import (
+	"CloudShoppingList/causalcontext"
 	"CloudShoppingList/crdt"
-	"bytes"
+	"CloudShoppingList/message"
+	"flag"
 	"fmt"
-	"io"
-	"mime/multipart"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 )
 
 type Client struct {
-	email          string
-	loadBalancerIP string
+	email string
+	// load balancers the client fails over between, the one in use first
+	loadBalancers []string
+	current       int
 }
 
-func NewClient(email string) *Client {
-	return &Client{email: email, loadBalancerIP: "localhost:8080"}
+func NewClient(email string, loadBalancers []string) *Client {
+	return &Client{email: email, loadBalancers: loadBalancers}
 }
 
-func (c *Client) push(filename string, maxRetries int, retryInterval time.Duration) int {
+// failOver sends a request with send to the load balancer in use, moving on to the next
+// one as long as it cannot be reached.
+func (c *Client) failOver(send func(loadBalancer string) (int, error)) (int, error) {
+	var err error
+	for range c.loadBalancers {
+		loadBalancer := c.loadBalancers[c.current]
+		var status int
+		status, err = send(loadBalancer)
+		if err == nil {
+			return status, nil
+		}
+		c.current = (c.current + 1) % len(c.loadBalancers)
+		fmt.Printf("Load balancer %s cannot be reached, trying %s: %v\n", loadBalancer, c.loadBalancers[c.current], err)
+	}
+	return 0, err
+}
 
-	url := fmt.Sprintf("http://%s/putList", c.loadBalancerIP)
+func (c *Client) push(filename string, maxRetries int, retryInterval time.Duration) int {
 
 	file_contents, err := os.ReadFile("../list_storage/" + c.email + "/" + filename)
 
@@ -33,48 +51,22 @@ func (c *Client) push(filename string, maxRetries int, retryInterval time.Durati
 		return -1
 	}
 
-	for retry := 0; retry < maxRetries; retry++ {
-
-		body := &bytes.Buffer{}
-		writer := multipart.NewWriter(body)
-		err := writer.WriteField("email", filename)
-		if err != nil {
-			fmt.Println("Error writing to form field:", err)
-			return 0
-		}
-
-		part, err := writer.CreateFormFile("file", filename)
-
-		if err != nil {
-			fmt.Println("Error creating form file:", err)
-			return -1
-		}
-		_, err = part.Write(file_contents)
-
-		if err != nil {
-			fmt.Println("Error writing to form file:", err)
-			return -1
-		}
-
-		err = writer.Close()
-
-		if err != nil {
-			fmt.Println("Error closing writer:", err)
-			return -1
-		}
-
-		fmt.Println("Body:", body)
-
-		req, err := http.NewRequest("POST", url, body)
-
-		if err != nil {
-			fmt.Println("Error creating request:", err)
-			return -1
-		}
+	// Send only what the servers have not seen when we know their causal context
+	list := crdt.FromGOB64(string(file_contents))
+	payload := file_contents
+	isDelta := false
+	serverContext := c.loadServerContext(filename)
+	if serverContext != nil {
+		payload = []byte(list.Delta(serverContext).ToGOB64())
+		isDelta = true
+	}
 
-		req.Header.Set("Content-Type", writer.FormDataContentType())
+	for retry := 0; retry < maxRetries; retry++ {
 
-		resp, err := http.DefaultClient.Do(req)
+		put := &message.PutList{Email: filename, List: string(payload), Delta: isDelta}
+		status, err := c.failOver(func(loadBalancer string) (int, error) {
+			return message.Post("http://"+loadBalancer+"/putList", put, nil)
+		})
 
 		if err != nil {
 			fmt.Printf("Error connecting to the server (retry %d/%d): %v\n", retry+1, maxRetries, err)
@@ -85,18 +77,19 @@ func (c *Client) push(filename string, maxRetries int, retryInterval time.Durati
 			retryInterval *= 2
 			continue
 		}
-		defer func(Body io.ReadCloser) {
-			err := Body.Close()
-			if err != nil {
-				fmt.Println(err)
-			}
-		}(resp.Body)
 
-		if resp.StatusCode == http.StatusOK {
+		if status == http.StatusOK {
 			fmt.Println("Pushed to the server successfully.")
-			return resp.StatusCode
+			c.saveServerContext(filename, list.Cc)
+			return status
+		}
+		if status == http.StatusPreconditionFailed && isDelta {
+			fmt.Println("The server does not have this list yet, pushing the full list.")
+			payload = file_contents
+			isDelta = false
+			continue
 		}
-		fmt.Printf("Error pushing to the server: %d.\n", resp.StatusCode)
+		fmt.Printf("Error pushing to the server: %d.\n", status)
 		time.Sleep(time.Duration(time.Second * 2))
 		retryInterval *= 2
 	}
@@ -107,18 +100,12 @@ func (c *Client) push(filename string, maxRetries int, retryInterval time.Durati
 
 func (c *Client) pull(filename string, maxRetries int, retryInterval time.Duration) int {
 
-	url := fmt.Sprintf("http://%s/list/%s", c.loadBalancerIP, filename)
-
 	for retry := 0; retry < maxRetries; retry++ {
 
-		req, err := http.NewRequest("GET", url, nil)
-
-		if err != nil {
-			fmt.Println("Error creating request:", err)
-			return -1
-		}
-
-		resp, err := http.DefaultClient.Do(req)
+		var response message.ShoppingList
+		status, err := c.failOver(func(loadBalancer string) (int, error) {
+			return message.Get("http://"+loadBalancer+"/list/"+filename, &response)
+		})
 
 		if err != nil {
 			fmt.Printf("Error connecting to the server (retry %d/%d): %v\n", retry+1, maxRetries, err)
@@ -129,33 +116,30 @@ func (c *Client) pull(filename string, maxRetries int, retryInterval time.Durati
 			retryInterval *= 2
 			continue
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode == http.StatusOK {
+		if status == http.StatusOK {
 			fmt.Println("Pulled from the server successfully.")
-			//read body
-			buf := new(bytes.Buffer)
-			_, err := buf.ReadFrom(resp.Body)
-			if err != nil {
-				fmt.Println("Error reading body:", err)
-				return 0
-			}
-			newList := crdt.FromGOB64(buf.String())
+			newList := crdt.FromGOB64(response.List)
+			c.saveServerContext(filename, newList.Cc)
 			//get old list
 			oldList := crdt.LoadFromFile(filename, c.email)
 			if oldList == nil {
 				oldList = crdt.NewList(c.email)
 				oldList.Join(newList)
 				oldList.SaveToFile(filename, c.email)
-				return resp.StatusCode
+				return status
 			}
 			//join old list with new list
 			oldList.Join(newList)
 			//save list to file
 			oldList.SaveToFile(filename, c.email)
-			return resp.StatusCode
+			return status
+		}
+		if status == http.StatusNotFound {
+			fmt.Println("The server has no list yet.")
+			return status
 		}
-		fmt.Printf("Error pulling from the server: %d.\n", resp.StatusCode)
+		fmt.Printf("Error pulling from the server: %d.\n", status)
 		time.Sleep(time.Duration(time.Second * 2))
 		retryInterval *= 2
 	}
@@ -164,6 +148,28 @@ func (c *Client) pull(filename string, maxRetries int, retryInterval time.Durati
 	return http.StatusInternalServerError
 }
 
+// loadServerContext returns the causal context the servers had for the list the last
+// time we synchronized with them, or nil when it is unknown.
+func (c *Client) loadServerContext(filename string) *causalcontext.CausalContext {
+	data, err := os.ReadFile("../list_storage/" + c.email + "/" + filename + ".context")
+	if err != nil {
+		return nil
+	}
+	serverContext, err := causalcontext.FromGOB64(string(data))
+	if err != nil {
+		fmt.Println("Error decoding server context:", err)
+		return nil
+	}
+	return serverContext
+}
+
+func (c *Client) saveServerContext(filename string, serverContext *causalcontext.CausalContext) {
+	err := os.WriteFile("../list_storage/"+c.email+"/"+filename+".context", []byte(serverContext.ToGOB64()), 0644)
+	if err != nil {
+		fmt.Println("Error saving server context:", err)
+	}
+}
+
 func (c *Client) makeShoppingList(email string) {
 	list := crdt.NewList(c.email)
 	fmt.Print("How many items do you want the list to have: ")
@@ -406,6 +412,8 @@ func (c *Client) menu() {
 }
 
 func main() {
+	loadBalancers := flag.String("lb", "localhost:8080", "comma separated addresses of the load balancers, used in turn when one cannot be reached")
+	flag.Parse()
 	fmt.Print("Enter your email: ")
 	var email string
 	_, err := fmt.Scanln(&email)
@@ -413,7 +421,7 @@ func main() {
 		fmt.Println("Error scanning input:", err)
 		return
 	}
-	client := NewClient(email)
+	client := NewClient(email, strings.Split(*loadBalancers, ","))
 	//create client dir inside list_storage folder if it doesn't exist
 	if _, err := os.Stat("../list_storage/" + email); os.IsNotExist(err) {
 		err := os.Mkdir("../list_storage/"+email, 0755)
@@ -2,7 +2,6 @@ package consistent
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"fmt"
 	"sort"
 	"strconv"
@@ -14,7 +13,11 @@ type Ring struct {
 	sync.RWMutex
 	virtualNodes      int
 	RealToVirtual     map[string][]string
-	ReplicationFactor int
+	replicationFactor int
+	hasher            Hasher
+	unavailable       map[string]bool
+	// epoch grows by one on every change of the members of the ring
+	epoch uint64
 }
 
 type Nodes []Node
@@ -25,6 +28,9 @@ type Node struct {
 	Server     string
 	IsVirtual  bool
 	RealNodeId string
+	// Zone is the host, rack or data center of the server, replicas are spread over distinct
+	// zones. Nodes without a zone are in a zone of their own.
+	Zone       string
 	FrontNodes []Node
 	BackNodes  []Node
 }
@@ -33,23 +39,59 @@ func (n Nodes) Len() int           { return len(n) }
 func (n Nodes) Less(i, j int) bool { return bytes.Compare(n[i].HashId, n[j].HashId) == -1 }
 func (n Nodes) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
 
-func NewRing() *Ring {
+// RingConfig is the shape of a ring: how many virtual nodes every real node gets, on
+// how many servers after the owner a key is replicated, N = 1 + ReplicationFactor, and
+// the hash function placing nodes and keys, SHA-256 when nil.
+type RingConfig struct {
+	VirtualNodes      int
+	ReplicationFactor int
+	Hasher            Hasher
+}
+
+// DefaultRingConfig places 3 virtual nodes per real node and keeps 3 copies of every key.
+func DefaultRingConfig() RingConfig {
+	return RingConfig{VirtualNodes: 3, ReplicationFactor: 2}
+}
+
+// VirtualNodesFor is the number of virtual nodes of a real node of the given weight. The real
+// node counts as a position too, so a node of weight 2 takes twice the positions of a node of
+// weight 1.
+func (config RingConfig) VirtualNodesFor(weight int) int {
+	if weight < 1 {
+		weight = 1
+	}
+	return weight*(config.VirtualNodes+1) - 1
+}
+
+// Validate checks that config describes a usable ring.
+func (config RingConfig) Validate() error {
+	if config.VirtualNodes < 0 {
+		return fmt.Errorf("the number of virtual nodes cannot be negative")
+	}
+	if config.ReplicationFactor < 1 {
+		return fmt.Errorf("the replication factor must be at least 1")
+	}
+	return nil
+}
+
+func NewRing(config RingConfig) *Ring {
+	if config.Hasher == nil {
+		config.Hasher = SHA256{}
+	}
 	return &Ring{
 		Nodes:             Nodes{},
-		virtualNodes:      3,
+		virtualNodes:      config.VirtualNodes,
 		RealToVirtual:     make(map[string][]string),
-		ReplicationFactor: 2,
+		replicationFactor: config.ReplicationFactor,
+		hasher:            config.Hasher,
+		unavailable:       make(map[string]bool),
 	}
 }
 
-func NewNode(id, server string, isVirtual bool, realNodeId string) *Node {
-	hash := sha256.New()
-	hash.Write([]byte(id))
-	hashId := hash.Sum(nil)
-
+func NewNode(hasher Hasher, id, server string, isVirtual bool, realNodeId string) *Node {
 	return &Node{
 		Id:         id,
-		HashId:     hashId,
+		HashId:     hasher.Hash([]byte(id)),
 		Server:     server,
 		IsVirtual:  isVirtual,
 		RealNodeId: realNodeId,
@@ -59,255 +101,591 @@ func NewNode(id, server string, isVirtual bool, realNodeId string) *Node {
 }
 
 func (r *Ring) AddNode(id, server string) {
+	r.AddWeightedNode(id, server, 1)
+}
+
+// AddWeightedNode places a real node with virtual nodes in proportion to weight, the capacity
+// of its server relative to a server of weight 1.
+func (r *Ring) AddWeightedNode(id, server string, weight int) {
+	r.Lock()
+	defer r.Unlock()
+	r.addNode(id, server, "", r.VirtualNodesFor(weight))
+	r.epoch++
+	r.updateNeighbors()
+}
+
+// VirtualNodesFor is the number of virtual nodes of a real node of the given weight in this ring.
+func (r *Ring) VirtualNodesFor(weight int) int {
+	return RingConfig{VirtualNodes: r.virtualNodes}.VirtualNodesFor(weight)
+}
+
+// SetWeight places the real node id again with the virtual nodes of weight. Its first virtual
+// nodes keep their positions, so only the ranges of the virtual nodes added or removed move.
+func (r *Ring) SetWeight(id string, weight int) error {
 	r.Lock()
 	defer r.Unlock()
-	realNode := NewNode(id, server, false, "")
+	if _, exists := r.RealToVirtual[id]; !exists {
+		return fmt.Errorf("node %s is not in the ring", id)
+	}
+	virtualNodes := r.VirtualNodesFor(weight)
+	r.Nodes = resized(r.hasher, r.Nodes, id, virtualNodes)
+	r.RealToVirtual[id] = []string{}
+	for i := 0; i < virtualNodes; i++ {
+		r.RealToVirtual[id] = append(r.RealToVirtual[id], id+"-"+strconv.Itoa(i))
+	}
+	r.epoch++
+	r.updateNeighbors()
+	return nil
+}
+
+// resized returns a sorted copy of nodes where the real node id has virtualNodes virtual nodes.
+func resized(hasher Hasher, nodes Nodes, id string, virtualNodes int) Nodes {
+	result := Nodes{}
+	var realNode Node
+	for _, node := range nodes {
+		if node.Id == id {
+			realNode = node
+		}
+		if node.Id != id && node.RealNodeId != id {
+			result = append(result, node)
+		}
+	}
+	result = append(result, realNode)
+	for i := 0; i < virtualNodes; i++ {
+		node := NewNode(hasher, id+"-"+strconv.Itoa(i), realNode.Server, true, id)
+		node.Zone = realNode.Zone
+		result = append(result, *node)
+	}
+	sort.Sort(result)
+	return result
+}
+
+// SetZone moves the real node id and its virtual nodes to zone.
+func (r *Ring) SetZone(id, zone string) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, exists := r.RealToVirtual[id]; !exists {
+		return fmt.Errorf("node %s is not in the ring", id)
+	}
+	for i := range r.Nodes {
+		if r.Nodes[i].Id == id || r.Nodes[i].RealNodeId == id {
+			r.Nodes[i].Zone = zone
+		}
+	}
+	r.epoch++
+	r.updateNeighbors()
+	return nil
+}
+
+// addNode places a real node and its virtual nodes on the ring, without sorting it.
+func (r *Ring) addNode(id, server, zone string, virtualNodes int) {
+	realNode := NewNode(r.hasher, id, server, false, "")
+	realNode.Zone = zone
 	r.Nodes = append(r.Nodes, *realNode)
 	r.RealToVirtual[id] = []string{}
-	for i := 0; i < r.virtualNodes; i++ {
+	for i := 0; i < virtualNodes; i++ {
 		virtualId := id + "-" + strconv.Itoa(i)
-		node := NewNode(virtualId, server, true, id)
+		node := NewNode(r.hasher, virtualId, server, true, id)
+		node.Zone = zone
 		r.Nodes = append(r.Nodes, *node)
 		r.RealToVirtual[id] = append(r.RealToVirtual[id], virtualId)
 	}
+}
+
+// updateNeighbors sorts the nodes and gives every node its front neighbours, the replicas of
+// the range ending at it, and its back neighbours, the nodes it is a front neighbour of. Both
+// come from the same placement, zones included.
+func (r *Ring) updateNeighbors() {
 	sort.Sort(r.Nodes)
-	for _, node := range r.Nodes {
-		r.GetNodeFrontNeighbors(node.Id)
-		r.GetNodeBackNeighbors(node.Id)
+	index := make(map[string]int, len(r.Nodes))
+	for i := range r.Nodes {
+		index[r.Nodes[i].Id] = i
+		r.Nodes[i].BackNodes = []Node{}
+	}
+	for i := range r.Nodes {
+		r.Nodes[i].FrontNodes = []Node{}
+		for _, front := range ownersAt(r.Nodes, i, r.replicationFactor, nil)[1:] {
+			r.Nodes[i].FrontNodes = append(r.Nodes[i].FrontNodes, neighbor(front))
+		}
+	}
+	for i := range r.Nodes {
+		for _, front := range r.Nodes[i].FrontNodes {
+			back := &r.Nodes[index[front.Id]]
+			back.BackNodes = append(back.BackNodes, neighbor(r.Nodes[i]))
+		}
+	}
+	// the nearest back neighbour first
+	for i := range r.Nodes {
+		distance := func(node Node) int {
+			return (i - index[node.Id] + len(r.Nodes)) % len(r.Nodes)
+		}
+		backNodes := r.Nodes[i].BackNodes
+		sort.Slice(backNodes, func(a, b int) bool { return distance(backNodes[a]) < distance(backNodes[b]) })
 	}
 }
 
-func (r *Ring) RemoveNode(id string) {
-	// removes a real node and its virtual nodes from the hash_ring
+// neighbor is node as the neighbour of another node, without neighbours of its own.
+func neighbor(node Node) Node {
+	node.FrontNodes = nil
+	node.BackNodes = nil
+	return node
 }
 
-func (r *Ring) GetNodeFrontNeighbors(id string) []Node {
-	if len(r.Nodes) == 0 {
-		return nil
+// Member is a real node of the ring, what it takes to place it on the ring again.
+type Member struct {
+	ID           string `json:"id"`
+	Server       string `json:"server"`
+	VirtualNodes int    `json:"virtual_nodes"`
+	Zone         string `json:"zone,omitempty"`
+}
+
+// Members returns the real nodes of the ring ordered by id, together with the epoch of the ring.
+func (r *Ring) Members() ([]Member, uint64) {
+	r.RLock()
+	defer r.RUnlock()
+	var members []Member
+	for _, node := range r.Nodes {
+		if !node.IsVirtual {
+			members = append(members, Member{ID: node.Id, Server: node.Server, VirtualNodes: len(r.RealToVirtual[node.Id]), Zone: node.Zone})
+		}
 	}
-	var neighbors []Node
-	for i := 0; i < len(r.Nodes); i++ {
-		if r.Nodes[i].Id == id {
-			idx := i
-			parentId := r.Nodes[i].RealNodeId
-			if parentId == "" {
-				parentId = r.Nodes[i].Id
-			}
-			forbiddenIds := make(map[string]bool)
-			forbiddenIds[parentId] = true
-			// Get the next 2 nodes in the nodes array excluding virtual nodes from the same real node
-			for j := 1; j <= r.ReplicationFactor; {
-				nextIndex := (idx + j) % len(r.Nodes)
-				if nextIndex == i {
-					break
-				}
-				nextNode := r.Nodes[nextIndex]
-				if nextNode.IsVirtual {
-					//check if the virtual node belongs to the same real node
-					if forbiddenIds[nextNode.RealNodeId] {
-						idx++
-						continue
-					}
-					neighbors = append(neighbors, nextNode)
-					forbiddenIds[nextNode.RealNodeId] = true
-					j++
-				} else {
-					if forbiddenIds[nextNode.Id] {
-						idx++
-						continue
-					}
-					neighbors = append(neighbors, nextNode)
-					forbiddenIds[nextNode.Id] = true
-					j++
-				}
-			}
-			r.Nodes[i].FrontNodes = neighbors
-			break
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID < members[j].ID
+	})
+	return members, r.epoch
+}
+
+// Restore replaces the nodes of the ring with members, at the given epoch. Members that
+// were already in the ring stay unavailable when they were.
+func (r *Ring) Restore(members []Member, epoch uint64) {
+	r.Lock()
+	defer r.Unlock()
+	unavailable := r.unavailable
+	r.Nodes = Nodes{}
+	r.RealToVirtual = make(map[string][]string)
+	r.unavailable = make(map[string]bool)
+	for _, member := range members {
+		r.addNode(member.ID, member.Server, member.Zone, member.VirtualNodes)
+		if unavailable[member.ID] {
+			r.unavailable[member.ID] = true
 		}
 	}
-	return neighbors
+	r.epoch = epoch
+	r.updateNeighbors()
 }
 
-func (r *Ring) GetNodeBackNeighbors(id string) []Node {
-	if len(r.Nodes) == 0 {
-		return nil
+func (r *Ring) RemoveNode(id string) {
+	// removes a real node and its virtual nodes from the hash_ring
+	r.Lock()
+	defer r.Unlock()
+	if _, exists := r.RealToVirtual[id]; !exists {
+		return
 	}
-	var neighbors []Node
-	for i := 0; i < len(r.Nodes); i++ {
-		if r.Nodes[i].Id == id {
-			idx := i
-			parentId := r.Nodes[i].RealNodeId
-			if parentId == "" {
-				parentId = r.Nodes[i].Id
-			}
-			forbiddenIds := make(map[string]bool)
-			forbiddenIds[parentId] = true
-			// Get the next 2 nodes in the nodes array excluding virtual nodes from the same real node
-			for j := 1; j <= r.ReplicationFactor; {
-				nextIndex := idx - j
-				if nextIndex < 0 {
-					nextIndex = len(r.Nodes) + nextIndex
-				}
-				if nextIndex == i {
-					break
-				}
-				nextNode := r.Nodes[nextIndex]
-				if nextNode.IsVirtual {
-					//check if the virtual node belongs to the same real node
-					if forbiddenIds[nextNode.RealNodeId] {
-						idx--
-						continue
-					}
-					neighbors = append(neighbors, nextNode)
-					forbiddenIds[nextNode.RealNodeId] = true
-					j++
-				} else {
-					if forbiddenIds[nextNode.Id] {
-						idx--
-						continue
-					}
-					neighbors = append(neighbors, nextNode)
-					forbiddenIds[nextNode.Id] = true
-					j++
-				}
-			}
-			r.Nodes[i].BackNodes = neighbors
-			break
+	remaining := Nodes{}
+	for _, node := range r.Nodes {
+		if node.Id == id || node.RealNodeId == id {
+			continue
 		}
+		remaining = append(remaining, node)
 	}
-	return neighbors
+	r.Nodes = remaining
+	delete(r.RealToVirtual, id)
+	delete(r.unavailable, id)
+	r.epoch++
+	r.updateNeighbors()
 }
 
-func (r *Ring) GetNodeAndReplicas(key string) ([]string, error) {
+// HasNode reports whether a real node with the given id is in the ring.
+func (r *Ring) HasNode(id string) bool {
+	r.RLock()
+	defer r.RUnlock()
+	_, exists := r.RealToVirtual[id]
+	return exists
+}
+
+// ReplicationFactor is the number of replicas after the owner of every key.
+func (r *Ring) ReplicationFactor() int {
 	r.RLock()
 	defer r.RUnlock()
+	return r.replicationFactor
+}
 
-	if len(r.Nodes) == 0 {
-		return nil, fmt.Errorf("ring is empty")
+// SetReplicationFactor changes the number of replicas after the owner of every key, a new
+// placement of the keys at the next epoch.
+func (r *Ring) SetReplicationFactor(replicationFactor int) {
+	r.Lock()
+	defer r.Unlock()
+	r.replicationFactor = replicationFactor
+	r.epoch++
+	r.updateNeighbors()
+}
+
+// VirtualNodes is the number of virtual nodes AddNode places for a real node of weight 1.
+func (r *Ring) VirtualNodes() int {
+	return r.virtualNodes
+}
+
+// Epoch is the version of the membership of the ring, it grows on every node added or removed.
+func (r *Ring) Epoch() uint64 {
+	r.RLock()
+	defer r.RUnlock()
+	return r.epoch
+}
+
+func (r *Ring) Name() string {
+	return "ring"
+}
+
+// config is the configuration of an empty ring like this one.
+func (r *Ring) config() RingConfig {
+	r.RLock()
+	defer r.RUnlock()
+	return RingConfig{VirtualNodes: r.virtualNodes, ReplicationFactor: r.replicationFactor, Hasher: r.hasher}
+}
+
+// Partitions returns the range ending at every node of the ring with its owner and replicas.
+func (r *Ring) Partitions() ([]Partition, uint64) {
+	r.RLock()
+	defer r.RUnlock()
+	return partitionsOf(r.Nodes, r.replicationFactor), r.epoch
+}
+
+// partitionsOf returns the range ending at every node of nodes, held by the node and the
+// replicas after it.
+func partitionsOf(nodes Nodes, replicationFactor int) []Partition {
+	partitions := []Partition{}
+	for i, node := range nodes {
+		previous := nodes[(i+len(nodes)-1)%len(nodes)]
+		partitions = append(partitions, Partition{
+			Range: KeyRange{Start: previous.HashId, End: node.HashId},
+			Nodes: neighbors(ownersAt(nodes, i, replicationFactor, nil)),
+		})
+	}
+	return partitions
+}
+
+// neighbors returns nodes without their own neighbours.
+func neighbors(nodes []Node) Nodes {
+	stripped := Nodes{}
+	for _, node := range nodes {
+		stripped = append(stripped, neighbor(node))
+	}
+	return stripped
+}
+
+// Snapshot returns a copy of the nodes of the ring together with its epoch, taken at once.
+func (r *Ring) Snapshot() (Nodes, uint64) {
+	r.RLock()
+	defer r.RUnlock()
+	nodes := make(Nodes, len(r.Nodes))
+	copy(nodes, r.Nodes)
+	return nodes, r.epoch
+}
+
+// KeyRange is the slice of the hash space (Start, End], all of it when Start is End.
+type KeyRange struct {
+	Start []byte
+	End   []byte
+}
+
+// Transfer is a key range that has to be copied from the server From to the server To.
+type Transfer struct {
+	Range KeyRange
+	From  string
+	To    string
+}
+
+// RemovalTransfers returns the key ranges the real node id holds (as owner or replica)
+// together with the servers that become responsible for them once the node leaves the ring.
+func (r *Ring) RemovalTransfers(id string) ([]Transfer, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if _, exists := r.RealToVirtual[id]; !exists {
+		return nil, fmt.Errorf("node %s is not in the ring", id)
 	}
+	remaining := Nodes{}
+	for _, node := range r.Nodes {
+		if node.Id != id && node.RealNodeId != id {
+			remaining = append(remaining, node)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil, fmt.Errorf("node %s is the last node in the ring", id)
+	}
+	return OwnerChanges(partitionsOf(r.Nodes, r.replicationFactor), partitionsOf(remaining, r.replicationFactor)), nil
+}
 
-	hash := sha256.New()
-	hash.Write([]byte(key))
-	keyHash := hash.Sum(nil)
+// ResizeTransfers returns the key ranges that change hands once the real node id has
+// virtualNodes virtual nodes: the ranges it gains come from the server that no longer holds
+// them, the ones it loses go to the servers taking them over.
+func (r *Ring) ResizeTransfers(id string, virtualNodes int) ([]Transfer, error) {
+	r.RLock()
+	defer r.RUnlock()
 
-	searchfn := func(i int) bool {
-		return bytes.Compare(r.Nodes[i].HashId, keyHash) != -1
+	if _, exists := r.RealToVirtual[id]; !exists {
+		return nil, fmt.Errorf("node %s is not in the ring", id)
 	}
+	after := resized(r.hasher, r.Nodes, id, virtualNodes)
+	return OwnerChanges(partitionsOf(r.Nodes, r.replicationFactor), partitionsOf(after, r.replicationFactor)), nil
+}
 
-	i := sort.Search(r.Nodes.Len(), searchfn)
-	if i >= r.Nodes.Len() {
-		i = 0
+// OwnerChanges compares the owners of every range before and after a change of the members,
+// and returns a transfer to every server that holds a range after but not before. The ranges
+// are cut at the partition ends of both, so each of them has a single set of owners in both.
+// A range is sent from a server that stops holding it, or from its owner when none does.
+func OwnerChanges(before, after []Partition) []Transfer {
+	if len(before) == 0 || len(after) == 0 {
+		return nil
+	}
+	// the union of both ends, in hash order
+	var bounds [][]byte
+	for i, j := 0, 0; i < len(before) || j < len(after); {
+		var next []byte
+		switch {
+		case j == len(after) || (i < len(before) && bytes.Compare(before[i].Range.End, after[j].Range.End) < 0):
+			next = before[i].Range.End
+			i++
+		case i == len(before) || bytes.Compare(after[j].Range.End, before[i].Range.End) < 0:
+			next = after[j].Range.End
+			j++
+		default:
+			next = before[i].Range.End
+			i++
+			j++
+		}
+		bounds = append(bounds, next)
 	}
-	servers := []string{r.Nodes[i].Server}
 
-	parentId := r.Nodes[i].Id
+	var transfers []Transfer
+	for k := range bounds {
+		end := bounds[k]
+		start := bounds[(k-1+len(bounds))%len(bounds)]
+		oldOwners := before[partitionAt(before, end)].Nodes
+		newOwners := after[partitionAt(after, end)].Nodes
+		from := oldOwners[0].Server
+		for _, owner := range oldOwners {
+			if !containsRealNode(newOwners, realId(owner)) {
+				from = owner.Server
+				break
+			}
+		}
+		for _, owner := range newOwners {
+			if containsRealNode(oldOwners, realId(owner)) {
+				continue
+			}
+			transfers = append(transfers, Transfer{Range: KeyRange{Start: start, End: end}, From: from, To: owner.Server})
+		}
+	}
+	return transfers
+}
 
-	if r.Nodes[i].IsVirtual {
-		parentId = r.Nodes[i].RealNodeId
+// positionOf returns the index of the first node of nodes whose hash is not smaller than hash.
+func positionOf(nodes Nodes, hash []byte) int {
+	i := sort.Search(nodes.Len(), func(i int) bool {
+		return bytes.Compare(nodes[i].HashId, hash) != -1
+	})
+	if i >= nodes.Len() {
+		i = 0
 	}
+	return i
+}
 
+// ownersAt returns the first node from position i onwards followed by the next
+// replicationFactor nodes that belong to distinct real nodes, stopping after one
+// full turn of the ring. Nodes in a zone that already holds one of them are passed over
+// while other zones are left, and take the remaining places in ring order when the ring
+// has too few zones. Nodes for which skip returns true are passed over.
+func ownersAt(nodes Nodes, i int, replicationFactor int, skip func(Node) bool) []Node {
+	owners := []Node{}
+	var sameZone []Node
 	forbiddenIds := make(map[string]bool)
-	forbiddenIds[parentId] = true
-
-	// Determine the next two nodes for replication
-	for j := 1; j <= r.ReplicationFactor; {
-		idToCheck := ""
-		if r.Nodes[(i+j)%len(r.Nodes)].IsVirtual {
-			idToCheck = r.Nodes[(i+j)%len(r.Nodes)].RealNodeId
-		} else {
-			idToCheck = r.Nodes[(i+j)%len(r.Nodes)].Id
+	zones := make(map[string]bool)
+	for j := 0; j < len(nodes) && len(owners) <= replicationFactor; j++ {
+		next := nodes[(i+j)%len(nodes)]
+		if forbiddenIds[realId(next)] || (skip != nil && skip(next)) {
+			continue
 		}
-		if !forbiddenIds[idToCheck] {
-			servers = append(servers, r.Nodes[(i+j)%len(r.Nodes)].Server)
-			forbiddenIds[idToCheck] = true
-			j++
-		} else {
-			i++
+		forbiddenIds[realId(next)] = true
+		if zones[next.Zone] {
+			sameZone = append(sameZone, next)
+			continue
+		}
+		owners = append(owners, next)
+		if next.Zone != "" {
+			zones[next.Zone] = true
 		}
 	}
+	for _, node := range sameZone {
+		if len(owners) > replicationFactor {
+			break
+		}
+		owners = append(owners, node)
+	}
+	return owners
+}
 
-	return servers, nil
+// SetAvailable marks a real node as reachable or not. Unavailable nodes stay in
+// the ring but are skipped when choosing the servers for a key.
+func (r *Ring) SetAvailable(id string, available bool) {
+	r.Lock()
+	defer r.Unlock()
+	if available {
+		delete(r.unavailable, id)
+	} else {
+		r.unavailable[id] = true
+	}
 }
 
-func (r *Ring) Get(key string) (string, error) {
+func (r *Ring) isUnavailable(node Node) bool {
+	return r.unavailable[realId(node)]
+}
+
+// position returns the index of the first node whose hash is not smaller than the hash of key.
+func (r *Ring) position(key string) int {
+	return positionOf(r.Nodes, r.hasher.Hash([]byte(key)))
+}
+
+// Hash is where key lies on the ring, what servers store a shopping list under to answer
+// the range queries of the ring.
+func (r *Ring) Hash(key string) []byte {
+	return r.Hasher().Hash([]byte(key))
+}
+
+// Hasher is the hash function placing the nodes and keys of the ring.
+func (r *Ring) Hasher() Hasher {
+	r.RLock()
+	defer r.RUnlock()
+	return r.hasher
+}
+
+// SetHasher places the nodes of the ring again with hasher, at the next epoch.
+func (r *Ring) SetHasher(hasher Hasher) {
+	r.Lock()
+	defer r.Unlock()
+	r.hasher = hasher
+	r.epoch++
+	for i := range r.Nodes {
+		r.Nodes[i].HashId = hasher.Hash([]byte(r.Nodes[i].Id))
+	}
+	r.updateNeighbors()
+}
+
+// StandIns maps every server that Put returns in place of an unavailable owner or
+// replica of key to the server it stands in for.
+func (r *Ring) StandIns(key string) map[string]string {
 	r.RLock()
 	defer r.RUnlock()
 
 	if len(r.Nodes) == 0 {
-		return "", fmt.Errorf("ring is empty")
+		return make(map[string]string)
 	}
+	return standInsAt(r.Nodes, r.position(key), r.replicationFactor, r.isUnavailable)
+}
 
-	hash := sha256.New()
-	hash.Write([]byte(key))
-	keyHash := hash.Sum(nil)
+func realId(node Node) string {
+	if node.IsVirtual {
+		return node.RealNodeId
+	}
+	return node.Id
+}
 
-	searchfn := func(i int) bool {
-		return bytes.Compare(r.Nodes[i].HashId, keyHash) != -1
+func containsRealNode(nodes []Node, id string) bool {
+	for _, node := range nodes {
+		if realId(node) == id {
+			return true
+		}
 	}
+	return false
+}
 
-	i := sort.Search(r.Nodes.Len(), searchfn)
-	if i >= r.Nodes.Len() {
-		i = 0
+// GetNodeFrontNeighbors returns the replicas of the range that ends at the node id, the
+// servers that come after it on the ring and hold the keys it owns.
+func (r *Ring) GetNodeFrontNeighbors(id string) []Node {
+	r.RLock()
+	defer r.RUnlock()
+	for _, node := range r.Nodes {
+		if node.Id == id {
+			return node.FrontNodes
+		}
 	}
+	return nil
+}
 
-	return r.Nodes[i].Server, nil
+// GetNodeBackNeighbors returns the nodes whose ranges the node id replicates, the nearest
+// first: the nodes that have it among their front neighbours.
+func (r *Ring) GetNodeBackNeighbors(id string) []Node {
+	r.RLock()
+	defer r.RUnlock()
+	for _, node := range r.Nodes {
+		if node.Id == id {
+			return node.BackNodes
+		}
+	}
+	return nil
 }
 
-func (r *Ring) Put(email string) ([]string, error) {
+// PreferenceList returns the natural owner and replicas of key, available or not.
+func (r *Ring) PreferenceList(key string) ([]string, error) {
 	r.RLock()
 	defer r.RUnlock()
 
 	if len(r.Nodes) == 0 {
 		return nil, fmt.Errorf("ring is empty")
 	}
+	return serversAt(r.Nodes, r.position(key), r.replicationFactor, nil), nil
+}
 
-	hash := sha256.New()
-	hash.Write([]byte(email))
-	emailHash := hash.Sum(nil)
-	fmt.Println("Email hash is ", emailHash)
+func (r *Ring) GetNodeAndReplicas(key string) ([]string, error) {
+	r.RLock()
+	defer r.RUnlock()
 
-	searchfn := func(i int) bool {
-		return bytes.Compare(r.Nodes[i].HashId, emailHash) != -1
+	if len(r.Nodes) == 0 {
+		return nil, fmt.Errorf("ring is empty")
 	}
 
-	i := sort.Search(r.Nodes.Len(), searchfn)
-	if i >= r.Nodes.Len() {
-		i = 0
+	// The owner followed by the next replicas on distinct real nodes,
+	// fewer when the ring does not have enough available servers
+	return serversAt(r.Nodes, r.position(key), r.replicationFactor, r.isUnavailable), nil
+}
+
+// HandoffCandidates returns, in ring order, the available servers that come after
+// the owner and replicas of key. They take writes meant for replicas that cannot be reached.
+func (r *Ring) HandoffCandidates(key string) ([]string, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if len(r.Nodes) == 0 {
+		return nil, fmt.Errorf("ring is empty")
 	}
-	servers := []string{r.Nodes[i].Server}
 
-	parentId := r.Nodes[i].Id
+	return handoffAt(r.Nodes, r.position(key), r.replicationFactor, r.isUnavailable), nil
+}
 
-	if r.Nodes[i].IsVirtual {
-		parentId = r.Nodes[i].RealNodeId
+func (r *Ring) Get(key string) (string, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if len(r.Nodes) == 0 {
+		return "", fmt.Errorf("ring is empty")
 	}
 
-	forbiddenIds := make(map[string]bool)
-	forbiddenIds[parentId] = true
-
-	// Determine the next two nodes for replication
-	idx := i
-	for j := 1; j <= r.ReplicationFactor; {
-		idToCheck := ""
-		if (i+j)%len(r.Nodes) == idx {
-			fmt.Println("No servers to satisfy replication factor")
-			return nil, fmt.Errorf("no servers to satisfy replication factor, please add more servers")
-		}
-		if r.Nodes[(i+j)%len(r.Nodes)].IsVirtual {
-			idToCheck = r.Nodes[(i+j)%len(r.Nodes)].RealNodeId
-		} else {
-			idToCheck = r.Nodes[(i+j)%len(r.Nodes)].Id
-		}
-		if !forbiddenIds[idToCheck] {
-			servers = append(servers, r.Nodes[(i+j)%len(r.Nodes)].Server)
-			forbiddenIds[idToCheck] = true
-			j++
-		} else {
-			i++
-		}
+	i := r.position(key)
+
+	return r.Nodes[i].Server, nil
+}
+
+func (r *Ring) Put(email string) ([]string, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if len(r.Nodes) == 0 {
+		return nil, fmt.Errorf("ring is empty")
 	}
 
-	return servers, nil
+	emailHash := r.hasher.Hash([]byte(email))
+	fmt.Println("Email hash is ", emailHash)
+	return putAt(r.Nodes, positionOf(r.Nodes, emailHash), r.replicationFactor, r.isUnavailable)
 }
 
 func (r *Ring) PrintNodes() {
@@ -0,0 +1,284 @@
+package consistent
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func testRing() *Ring {
+	return testRingWith(nil)
+}
+
+func testRingWith(hasher Hasher) *Ring {
+	config := DefaultRingConfig()
+	config.Hasher = hasher
+	ring := NewRing(config)
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		ring.AddNode(id, "server-"+id)
+	}
+	return ring
+}
+
+// inRange reports whether hash falls in (start, end], which wraps around the ring when
+// start is not smaller than end.
+func inRange(hash []byte, keyRange KeyRange) bool {
+	afterStart := bytes.Compare(hash, keyRange.Start) > 0
+	upToEnd := bytes.Compare(hash, keyRange.End) <= 0
+	if bytes.Compare(keyRange.Start, keyRange.End) < 0 {
+		return afterStart && upToEnd
+	}
+	return afterStart || upToEnd
+}
+
+// checkTransfers checks that every server holding a key after change and not before gets
+// the key from one of transfers.
+func checkTransfers(t *testing.T, ring Partitioner, transfers []Transfer, change func()) {
+	t.Helper()
+	before := make(map[string][]string)
+	for i := 0; i < 500; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		before[email], _ = ring.Put(email)
+	}
+	change()
+	for email, oldOwners := range before {
+		newOwners, _ := ring.Put(email)
+		hash := ring.Hasher().Hash([]byte(email))
+		for _, owner := range newOwners {
+			if contains(oldOwners, owner) {
+				continue
+			}
+			covered := false
+			for _, transfer := range transfers {
+				if transfer.To == owner && inRange(hash, transfer.Range) && contains(oldOwners, transfer.From) {
+					covered = true
+				}
+			}
+			if !covered {
+				t.Errorf("%s moves to %s without a transfer", email, owner)
+			}
+		}
+	}
+}
+
+func contains(servers []string, server string) bool {
+	for _, s := range servers {
+		if s == server {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWeightScalesVirtualNodes(t *testing.T) {
+	ring := testRing()
+	ring.AddWeightedNode("i", "server-i", 3)
+	members, _ := ring.Members()
+	for _, member := range members {
+		want := 3
+		if member.ID == "i" {
+			want = 11
+		}
+		if member.VirtualNodes != want {
+			t.Errorf("%s has %d virtual nodes, want %d", member.ID, member.VirtualNodes, want)
+		}
+	}
+}
+
+func TestRemovalTransfers(t *testing.T) {
+	ring := testRing()
+	transfers, err := ring.RemovalTransfers("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, transfer := range transfers {
+		if transfer.From != "server-b" || transfer.To == "server-b" {
+			t.Errorf("transfer from %s to %s", transfer.From, transfer.To)
+		}
+	}
+	checkTransfers(t, ring, transfers, func() { ring.RemoveNode("b") })
+}
+
+func TestRemoveNode(t *testing.T) {
+	ring := testRing()
+	before := make(map[string]string)
+	for i := 0; i < 500; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		before[email], _ = ring.Get(email)
+	}
+	epoch := ring.Epoch()
+	ring.RemoveNode("b")
+	if ring.HasNode("b") || ring.Epoch() != epoch+1 {
+		t.Fatalf("b still in the ring at epoch %d", ring.Epoch())
+	}
+	for _, node := range ring.Nodes {
+		if node.Server == "server-b" {
+			t.Errorf("virtual node %s of b left behind", node.Id)
+		}
+	}
+	// only the keys b owned change owner
+	for email, owner := range before {
+		after, _ := ring.Get(email)
+		if owner != "server-b" && after != owner {
+			t.Errorf("%s moved from %s to %s", email, owner, after)
+		}
+	}
+	ring.RemoveNode("b")
+	if ring.Epoch() != epoch+1 {
+		t.Error("removing an unknown node changed the epoch")
+	}
+}
+
+func TestRemovalTransfersOfUnknownAndLastNodes(t *testing.T) {
+	ring := testRing()
+	if _, err := ring.RemovalTransfers("z"); err == nil {
+		t.Error("transfers of an unknown node")
+	}
+	lonely := NewRing(DefaultRingConfig())
+	lonely.AddNode("a", "server-a")
+	if _, err := lonely.RemovalTransfers("a"); err == nil {
+		t.Error("transfers of the last node")
+	}
+}
+
+func TestResizeTransfers(t *testing.T) {
+	for _, weight := range []int{3, 1} {
+		ring := testRing()
+		ring.SetWeight("a", 2)
+		transfers, err := ring.ResizeTransfers("a", ring.VirtualNodesFor(weight))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transfers) == 0 {
+			t.Fatalf("no transfers to weight %d", weight)
+		}
+		for _, transfer := range transfers {
+			// a server gaining positions only receives ranges, one losing them only sends
+			if weight > 2 && transfer.To != "server-a" || weight < 2 && transfer.From != "server-a" {
+				t.Errorf("weight %d: transfer from %s to %s", weight, transfer.From, transfer.To)
+			}
+		}
+		checkTransfers(t, ring, transfers, func() { ring.SetWeight("a", weight) })
+	}
+}
+
+func TestReplicasSpreadOverZones(t *testing.T) {
+	for _, zones := range []int{3, 2} {
+		ring := testRing()
+		zoneOf := make(map[string]string)
+		for i, id := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+			zoneOf["server-"+id] = fmt.Sprintf("zone-%d", i%zones)
+			ring.SetZone(id, zoneOf["server-"+id])
+		}
+		for i := 0; i < 200; i++ {
+			servers, err := ring.Put(fmt.Sprintf("user%d@example.com", i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			distinct := make(map[string]bool)
+			for _, server := range servers {
+				distinct[zoneOf[server]] = true
+			}
+			if len(servers) != 3 || len(distinct) != zones {
+				t.Fatalf("%d zones: replicas %v are in %d zones", zones, servers, len(distinct))
+			}
+		}
+		// the replicas of the range of a node are its front neighbours, and it is a back
+		// neighbour of each of them
+		backs := 0
+		for _, node := range ring.Nodes {
+			for _, front := range node.FrontNodes {
+				if front.Zone == node.Zone && zones == 3 {
+					t.Errorf("%s and its neighbour %s are both in %s", node.Id, front.Id, node.Zone)
+				}
+				found := false
+				for _, back := range ring.GetNodeBackNeighbors(front.Id) {
+					found = found || back.Id == node.Id
+				}
+				if !found {
+					t.Errorf("%s is not a back neighbour of its front neighbour %s", node.Id, front.Id)
+				}
+			}
+			backs += len(node.BackNodes)
+		}
+		if backs != 2*len(ring.Nodes) {
+			t.Errorf("%d back neighbours for %d front neighbours", backs, 2*len(ring.Nodes))
+		}
+	}
+}
+
+func TestHashers(t *testing.T) {
+	for _, name := range []string{"sha256", "xxhash", "murmur3"} {
+		hasher, err := NewHasher(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hasher.Name() != name {
+			t.Errorf("hasher %s is called %s", name, hasher.Name())
+		}
+		if len(hasher.Hash([]byte("a"))) != len(hasher.Hash([]byte("a longer key"))) {
+			t.Errorf("%s hashes have different lengths", name)
+		}
+		ring := testRingWith(hasher)
+		transfers, err := ring.RemovalTransfers("b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkTransfers(t, ring, transfers, func() { ring.RemoveNode("b") })
+	}
+	if _, err := NewHasher("md5"); err == nil {
+		t.Error("unknown hash function accepted")
+	}
+}
+
+func TestPreferenceListKeepsUnavailableServers(t *testing.T) {
+	ring := testRing()
+	email := "user1@example.com"
+	natural, _ := ring.PreferenceList(email)
+	owner, _ := ring.Get(email)
+	ring.SetAvailable(owner[len("server-"):], false)
+	preference, _ := ring.PreferenceList(email)
+	if fmt.Sprint(preference) != fmt.Sprint(natural) || preference[0] != owner {
+		t.Errorf("preference list went from %v to %v", natural, preference)
+	}
+	routed, _ := ring.GetNodeAndReplicas(email)
+	if contains(routed, owner) {
+		t.Errorf("unavailable %s still routed to in %v", owner, routed)
+	}
+}
+
+func TestPlacementChangesRaiseTheEpoch(t *testing.T) {
+	for _, partitioner := range []Partitioner{testRing(), NewRendezvous(DefaultRingConfig())} {
+		epoch := partitioner.Epoch()
+		partitioner.SetReplicationFactor(1)
+		if partitioner.Epoch() <= epoch {
+			t.Errorf("%T: a new replication factor kept epoch %d", partitioner, epoch)
+		}
+		epoch = partitioner.Epoch()
+		partitioner.SetHasher(XXHash{})
+		if partitioner.Epoch() <= epoch {
+			t.Errorf("%T: a new hash function kept epoch %d", partitioner, epoch)
+		}
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	emails := make([]string, 1024)
+	for i := range emails {
+		emails[i] = fmt.Sprintf("user%d@example.com", i)
+	}
+	for _, name := range []string{"sha256", "xxhash", "murmur3"} {
+		hasher, _ := NewHasher(name)
+		config := DefaultRingConfig()
+		config.Hasher = hasher
+		ring := NewRing(config)
+		for i := 0; i < 100; i++ {
+			ring.AddNode(fmt.Sprintf("node%d", i), fmt.Sprintf("localhost:%d", 9000+i))
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ring.GetNodeAndReplicas(emails[i%len(emails)])
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package consistent
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/spaolacci/murmur3"
+)
+
+// Hasher places nodes and keys on the ring. Hashes are compared as big endian numbers, so
+// every hash of a Hasher has the same length.
+type Hasher interface {
+	Hash(key []byte) []byte
+	// Name is what the hasher is chosen by, see NewHasher
+	Name() string
+}
+
+// NewHasher returns the hasher called name: "sha256", the default when name is empty,
+// "xxhash" or "murmur3".
+func NewHasher(name string) (Hasher, error) {
+	switch name {
+	case "", "sha256":
+		return SHA256{}, nil
+	case "xxhash":
+		return XXHash{}, nil
+	case "murmur3":
+		return Murmur3{}, nil
+	}
+	return nil, fmt.Errorf("unknown hash function %q", name)
+}
+
+// SHA256 spreads keys over 256 bits, slower than the others but what the ring always used.
+type SHA256 struct{}
+
+func (SHA256) Hash(key []byte) []byte {
+	hash := sha256.Sum256(key)
+	return hash[:]
+}
+
+func (SHA256) Name() string { return "sha256" }
+
+// XXHash is the 64 bits xxHash, the fastest of the hashers.
+type XXHash struct{}
+
+func (XXHash) Hash(key []byte) []byte {
+	return binary.BigEndian.AppendUint64(nil, xxhash.Sum64(key))
+}
+
+func (XXHash) Name() string { return "xxhash" }
+
+// Murmur3 is the 128 bits MurmurHash3.
+type Murmur3 struct{}
+
+func (Murmur3) Hash(key []byte) []byte {
+	high, low := murmur3.Sum128(key)
+	return binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint64(nil, high), low)
+}
+
+func (Murmur3) Name() string { return "murmur3" }
@@ -0,0 +1,631 @@
+package consistent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Partitioner chooses the servers of a key among the members it was given: an owner followed
+// by ReplicationFactor replicas on distinct real nodes, spread over distinct zones when it can.
+// Ring places members on a circle of virtual nodes, Rendezvous and Jump rank the members for
+// fixed slices of the hash space and BoundedLoad caps how much of the ring a server owns. The
+// load balancers route with the one the membership names, and the servers move keys along the
+// ranges of its Partitions.
+type Partitioner interface {
+	// Name is what NewPartitioner knows the partitioner by.
+	Name() string
+	// Restore replaces the members at the given epoch. Members that were already there stay
+	// unavailable when they were.
+	Restore(members []Member, epoch uint64)
+	// Members returns the members ordered by id, together with the epoch.
+	Members() ([]Member, uint64)
+	Epoch() uint64
+	SetAvailable(id string, available bool)
+	ReplicationFactor() int
+	SetReplicationFactor(replicationFactor int)
+	Hasher() Hasher
+	SetHasher(hasher Hasher)
+	// Put returns the available owner and replicas of key, failing when there are fewer
+	// members than 1 + ReplicationFactor.
+	Put(key string) ([]string, error)
+	// Get returns the owner of key, available or not.
+	Get(key string) (string, error)
+	// GetNodeAndReplicas returns the available owner and replicas of key, fewer when there
+	// are not enough available members.
+	GetNodeAndReplicas(key string) ([]string, error)
+	// PreferenceList returns the natural owner and replicas of key, available or not.
+	PreferenceList(key string) ([]string, error)
+	// HandoffCandidates returns, in order, the available servers that come after the owner
+	// and replicas of key.
+	HandoffCandidates(key string) ([]string, error)
+	// StandIns maps every server Put returns in place of an unavailable owner or replica of
+	// key to the server it stands in for.
+	StandIns(key string) map[string]string
+	// Partitions returns the placement of every key hash together with the epoch: the hash
+	// space cut in ranges ordered by their end, the first one wrapping around.
+	Partitions() ([]Partition, uint64)
+	// Plan returns the key ranges that move if change is applied to the members, each from a
+	// server holding it now to a server holding it after. The partitioner does not change.
+	Plan(change Change) ([]Transfer, error)
+}
+
+// Partition is a range of key hashes with the nodes holding its keys, the owner followed by
+// the replicas, available or not. Node ids are ring positions or members, realId gives the member.
+type Partition struct {
+	Range KeyRange
+	Nodes Nodes
+}
+
+// partitionAt returns the index of the partition holding hash.
+func partitionAt(partitions []Partition, hash []byte) int {
+	i := sort.Search(len(partitions), func(i int) bool {
+		return bytes.Compare(partitions[i].Range.End, hash) != -1
+	})
+	if i >= len(partitions) {
+		i = 0
+	}
+	return i
+}
+
+// NewPartitioner returns the partitioner called name: "ring" (the default when name is
+// empty), "rendezvous", "jump" or "bounded".
+func NewPartitioner(name string, config RingConfig) (Partitioner, error) {
+	switch name {
+	case "", "ring":
+		return NewRing(config), nil
+	case "rendezvous":
+		return NewRendezvous(config), nil
+	case "jump":
+		return NewJump(config), nil
+	case "bounded":
+		return NewBoundedLoad(config, DefaultBalance), nil
+	}
+	return nil, fmt.Errorf("unknown partitioner %q", name)
+}
+
+// serversAt returns the servers of ownersAt.
+func serversAt(nodes Nodes, i int, replicationFactor int, skip func(Node) bool) []string {
+	servers := []string{}
+	for _, node := range ownersAt(nodes, i, replicationFactor, skip) {
+		servers = append(servers, node.Server)
+	}
+	return servers
+}
+
+// putAt returns the available owner and replicas from position i of nodes, see Partitioner.Put.
+func putAt(nodes Nodes, i int, replicationFactor int, unavailable func(Node) bool) ([]string, error) {
+	// Make sure the ring has enough servers for the replication factor
+	if len(ownersAt(nodes, i, replicationFactor, nil)) <= replicationFactor {
+		fmt.Println("No servers to satisfy replication factor")
+		return nil, fmt.Errorf("no servers to satisfy replication factor, please add more servers")
+	}
+
+	// Dead servers are skipped, the next available ones take their place
+	servers := serversAt(nodes, i, replicationFactor, unavailable)
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no available servers")
+	}
+	return servers, nil
+}
+
+// handoffAt returns the available servers from position i of nodes that come after the
+// owner and replicas.
+func handoffAt(nodes Nodes, i int, replicationFactor int, unavailable func(Node) bool) []string {
+	servers := []string{}
+	for j, node := range ownersAt(nodes, i, len(nodes), unavailable) {
+		if j > replicationFactor {
+			servers = append(servers, node.Server)
+		}
+	}
+	return servers
+}
+
+// standInsAt maps the servers standing in for the unavailable owner and replicas from
+// position i of nodes to the servers they stand in for.
+func standInsAt(nodes Nodes, i int, replicationFactor int, unavailable func(Node) bool) map[string]string {
+	standIns := make(map[string]string)
+	natural := ownersAt(nodes, i, replicationFactor, nil)
+	var missing []string
+	for _, node := range natural {
+		if unavailable(node) {
+			missing = append(missing, node.Server)
+		}
+	}
+	for _, node := range ownersAt(nodes, i, replicationFactor, unavailable) {
+		if len(missing) == 0 {
+			break
+		}
+		if containsRealNode(natural, realId(node)) {
+			continue
+		}
+		standIns[node.Server] = missing[0]
+		missing = missing[1:]
+	}
+	return standIns
+}
+
+// sliceBits is the number of leading bits of a key hash that choose its slice, Rendezvous and
+// Jump rank the members for each of the 1 << sliceBits slices of the hash space rather than for
+// every key, so their placement is made of ranges the servers can move.
+const sliceBits = 8
+
+// sliceOf is the slice of the hash space holding hash.
+func sliceOf(hash []byte) int {
+	return int(uint64Of(hash) >> (64 - sliceBits))
+}
+
+// sliceEnd is the last hash of a slice, as long as the hashes of hasher: the leading bits are
+// the slice and all the others are set.
+func sliceEnd(hasher Hasher, slice int) []byte {
+	end := bytes.Repeat([]byte{0xff}, len(hasher.Hash(nil)))
+	var leading [8]byte
+	binary.BigEndian.PutUint64(leading[:], uint64(slice)<<(64-sliceBits)|(1<<(64-sliceBits)-1))
+	copy(end, leading[:])
+	return end
+}
+
+// ranking is the state shared by the partitioners that rank every member for a slice of the
+// hash space and take the first ranked ones, rank orders the members for a slice.
+type ranking struct {
+	sync.RWMutex
+	name              string
+	members           Nodes
+	restored          []Member
+	weights           map[string]float64
+	replicationFactor int
+	hasher            Hasher
+	unavailable       map[string]bool
+	epoch             uint64
+	rank              func(slice int) Nodes
+}
+
+func newRanking(name string, config RingConfig) *ranking {
+	if config.Hasher == nil {
+		config.Hasher = SHA256{}
+	}
+	return &ranking{
+		name:              name,
+		replicationFactor: config.ReplicationFactor,
+		hasher:            config.Hasher,
+		weights:           make(map[string]float64),
+		unavailable:       make(map[string]bool),
+	}
+}
+
+func (p *ranking) Name() string {
+	return p.name
+}
+
+// config is the configuration of an empty partitioner like this one.
+func (p *ranking) config() RingConfig {
+	p.RLock()
+	defer p.RUnlock()
+	return RingConfig{ReplicationFactor: p.replicationFactor, Hasher: p.hasher}
+}
+
+// Restore takes members ordered by id, a member weighs as many positions as it would take
+// on the ring.
+func (p *ranking) Restore(members []Member, epoch uint64) {
+	p.Lock()
+	defer p.Unlock()
+	unavailable := p.unavailable
+	p.members = Nodes{}
+	p.restored = append([]Member{}, members...)
+	p.weights = make(map[string]float64)
+	p.unavailable = make(map[string]bool)
+	for _, member := range members {
+		p.members = append(p.members, Node{Id: member.ID, Server: member.Server, Zone: member.Zone})
+		p.weights[member.ID] = float64(member.VirtualNodes + 1)
+		if unavailable[member.ID] {
+			p.unavailable[member.ID] = true
+		}
+	}
+	sort.Slice(p.members, func(i, j int) bool {
+		return p.members[i].Id < p.members[j].Id
+	})
+	sort.Slice(p.restored, func(i, j int) bool {
+		return p.restored[i].ID < p.restored[j].ID
+	})
+	p.epoch = epoch
+}
+
+func (p *ranking) Members() ([]Member, uint64) {
+	p.RLock()
+	defer p.RUnlock()
+	return append([]Member{}, p.restored...), p.epoch
+}
+
+func (p *ranking) Epoch() uint64 {
+	p.RLock()
+	defer p.RUnlock()
+	return p.epoch
+}
+
+func (p *ranking) SetAvailable(id string, available bool) {
+	p.Lock()
+	defer p.Unlock()
+	if available {
+		delete(p.unavailable, id)
+	} else {
+		p.unavailable[id] = true
+	}
+}
+
+func (p *ranking) isUnavailable(node Node) bool {
+	return p.unavailable[node.Id]
+}
+
+func (p *ranking) ReplicationFactor() int {
+	p.RLock()
+	defer p.RUnlock()
+	return p.replicationFactor
+}
+
+func (p *ranking) SetReplicationFactor(replicationFactor int) {
+	p.Lock()
+	defer p.Unlock()
+	p.replicationFactor = replicationFactor
+	p.epoch++
+}
+
+func (p *ranking) Hasher() Hasher {
+	p.RLock()
+	defer p.RUnlock()
+	return p.hasher
+}
+
+func (p *ranking) SetHasher(hasher Hasher) {
+	p.Lock()
+	defer p.Unlock()
+	p.hasher = hasher
+	p.epoch++
+}
+
+// sliceHash is the hash a slice is ranked by, the caller holds the lock.
+func (p *ranking) sliceHash(slice int) []byte {
+	var index [8]byte
+	binary.BigEndian.PutUint64(index[:], uint64(slice))
+	return p.hasher.Hash(index[:])
+}
+
+// order ranks the members for the slice of key, the caller holds the lock.
+func (p *ranking) order(key string) Nodes {
+	return p.rank(sliceOf(p.hasher.Hash([]byte(key))))
+}
+
+func (p *ranking) Put(key string) ([]string, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if len(p.members) == 0 {
+		return nil, fmt.Errorf("ring is empty")
+	}
+	return putAt(p.order(key), 0, p.replicationFactor, p.isUnavailable)
+}
+
+func (p *ranking) Get(key string) (string, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if len(p.members) == 0 {
+		return "", fmt.Errorf("ring is empty")
+	}
+	return p.order(key)[0].Server, nil
+}
+
+func (p *ranking) GetNodeAndReplicas(key string) ([]string, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if len(p.members) == 0 {
+		return nil, fmt.Errorf("ring is empty")
+	}
+	return serversAt(p.order(key), 0, p.replicationFactor, p.isUnavailable), nil
+}
+
+func (p *ranking) PreferenceList(key string) ([]string, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if len(p.members) == 0 {
+		return nil, fmt.Errorf("ring is empty")
+	}
+	return serversAt(p.order(key), 0, p.replicationFactor, nil), nil
+}
+
+func (p *ranking) HandoffCandidates(key string) ([]string, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if len(p.members) == 0 {
+		return nil, fmt.Errorf("ring is empty")
+	}
+	return handoffAt(p.order(key), 0, p.replicationFactor, p.isUnavailable), nil
+}
+
+func (p *ranking) StandIns(key string) map[string]string {
+	p.RLock()
+	defer p.RUnlock()
+	if len(p.members) == 0 {
+		return make(map[string]string)
+	}
+	return standInsAt(p.order(key), 0, p.replicationFactor, p.isUnavailable)
+}
+
+// Partitions returns the slices of the hash space, neighbouring slices held by the same
+// servers merged into one range.
+func (p *ranking) Partitions() ([]Partition, uint64) {
+	p.RLock()
+	defer p.RUnlock()
+	var partitions []Partition
+	if len(p.members) == 0 {
+		return partitions, p.epoch
+	}
+	start := sliceEnd(p.hasher, 1<<sliceBits-1)
+	for slice := 0; slice < 1<<sliceBits; slice++ {
+		end := sliceEnd(p.hasher, slice)
+		owners := Nodes(ownersAt(p.rank(slice), 0, p.replicationFactor, nil))
+		last := len(partitions) - 1
+		if last >= 0 && sameServers(partitions[last].Nodes, owners) {
+			partitions[last].Range.End = end
+		} else {
+			partitions = append(partitions, Partition{Range: KeyRange{Start: start, End: end}, Nodes: owners})
+		}
+		start = end
+	}
+	return partitions, p.epoch
+}
+
+// sameServers reports whether a and b are the same servers in the same order.
+func sameServers(a, b Nodes) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Server != b[i].Server {
+			return false
+		}
+	}
+	return true
+}
+
+// uint64Of is the first 64 bits of a hash.
+func uint64Of(hash []byte) uint64 {
+	var padded [8]byte
+	copy(padded[:], hash)
+	return binary.BigEndian.Uint64(padded[:])
+}
+
+// Rendezvous is highest random weight hashing: every member scores every slice of the hash
+// space and the highest scores own it. A member that leaves only moves the slices it held.
+type Rendezvous struct {
+	*ranking
+}
+
+func NewRendezvous(config RingConfig) *Rendezvous {
+	rendezvous := &Rendezvous{newRanking("rendezvous", config)}
+	rendezvous.rank = rendezvous.scores
+	return rendezvous
+}
+
+func (r *Rendezvous) Plan(change Change) ([]Transfer, error) {
+	return planChange(r, func() Partitioner { return NewRendezvous(r.config()) }, change)
+}
+
+// scores orders the members by the weighted score -weight/ln(h), h being the hash of the
+// member and the slice mapped to (0, 1).
+func (r *Rendezvous) scores(slice int) Nodes {
+	order := make(Nodes, len(r.members))
+	copy(order, r.members)
+	sliceHash := r.sliceHash(slice)
+	score := make(map[string]float64, len(order))
+	for _, node := range order {
+		hash := uint64Of(r.hasher.Hash(append([]byte(node.Id), sliceHash...)))
+		unit := (float64(hash>>11) + 0.5) / (1 << 53)
+		score[node.Id] = -r.weights[node.Id] / math.Log(unit)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return score[order[i].Id] > score[order[j].Id]
+	})
+	return order
+}
+
+// Jump is the jump consistent hash of Lamping and Veach over the members ordered by id, the
+// replicas are jumped to among the members left. It only moves few slices when members are
+// added or removed at the end of that order, and ignores weights.
+type Jump struct {
+	*ranking
+}
+
+func NewJump(config RingConfig) *Jump {
+	jump := &Jump{newRanking("jump", config)}
+	jump.rank = jump.jumps
+	return jump
+}
+
+func (j *Jump) Plan(change Change) ([]Transfer, error) {
+	return planChange(j, func() Partitioner { return NewJump(j.config()) }, change)
+}
+
+func (j *Jump) jumps(slice int) Nodes {
+	remaining := make(Nodes, len(j.members))
+	copy(remaining, j.members)
+	order := Nodes{}
+	key := uint64Of(j.sliceHash(slice))
+	for len(remaining) > 0 {
+		bucket := jumpHash(key, len(remaining))
+		order = append(order, remaining[bucket])
+		remaining = append(remaining[:bucket], remaining[bucket+1:]...)
+		// a different key for the next jump
+		key = key*2862933555777941757 + 1
+	}
+	return order
+}
+
+// jumpHash maps key to a bucket in [0, buckets).
+func jumpHash(key uint64, buckets int) int {
+	b, j := int64(-1), int64(0)
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// DefaultBalance lets a server of a BoundedLoad partitioner own 25% more of the hash space than
+// its share.
+const DefaultBalance = 1.25
+
+// BoundedLoad is consistent hashing with bounded loads over the ranges of a ring: in ring
+// order, every range is owned by the first server from its position that owns less than
+// Balance times its share of the hash space, and replicated on the next ones. A server's share
+// is its share of the positions. The owners only depend on the members, so every load balancer
+// and server places keys alike.
+type BoundedLoad struct {
+	*Ring
+	Balance float64
+	// the real nodes holding the range ending at every position of the ring, owner first
+	orders []Nodes
+}
+
+func NewBoundedLoad(config RingConfig, balance float64) *BoundedLoad {
+	return &BoundedLoad{Ring: NewRing(config), Balance: balance}
+}
+
+func (b *BoundedLoad) Name() string {
+	return "bounded"
+}
+
+// Restore places the members and assigns the ranges of the ring.
+func (b *BoundedLoad) Restore(members []Member, epoch uint64) {
+	b.Ring.Restore(members, epoch)
+	b.assign()
+}
+
+func (b *BoundedLoad) SetReplicationFactor(replicationFactor int) {
+	b.Ring.SetReplicationFactor(replicationFactor)
+	b.assign()
+}
+
+func (b *BoundedLoad) SetHasher(hasher Hasher) {
+	b.Ring.SetHasher(hasher)
+	b.assign()
+}
+
+// assign gives every range of the ring its owner in ring order, the load of a server being the
+// part of the hash space it owns.
+func (b *BoundedLoad) assign() {
+	b.Ring.Lock()
+	defer b.Ring.Unlock()
+	nodes := b.Ring.Nodes
+	b.orders = make([]Nodes, len(nodes))
+	share := make(map[string]float64)
+	for _, node := range nodes {
+		share[realId(node)] += 1 / float64(len(nodes))
+	}
+	loads := make(map[string]float64)
+	for i := range nodes {
+		order := ownersAt(nodes, i, len(nodes), nil)
+		width := 1.0
+		if len(nodes) > 1 {
+			width = float64(uint64Of(nodes[i].HashId)-uint64Of(nodes[(i+len(nodes)-1)%len(nodes)].HashId)) / math.Pow(2, 64)
+		}
+		// the least loaded server takes the range when every one is full
+		owner := 0
+		for j, node := range order {
+			id := realId(node)
+			if loads[id]+width <= b.Balance*share[id] {
+				owner = j
+				break
+			}
+			if loads[id]/share[id] < loads[realId(order[owner])]/share[realId(order[owner])] {
+				owner = j
+			}
+		}
+		loads[realId(order[owner])] += width
+		b.orders[i] = append(append(Nodes{}, order[owner:]...), order[:owner]...)
+	}
+}
+
+// order returns the real nodes holding the range of key, starting at its owner.
+func (b *BoundedLoad) order(key string) Nodes {
+	b.Ring.RLock()
+	defer b.Ring.RUnlock()
+	if len(b.orders) == 0 {
+		return nil
+	}
+	return b.orders[b.Ring.position(key)]
+}
+
+func (b *BoundedLoad) Put(key string) ([]string, error) {
+	order := b.order(key)
+	if len(order) == 0 {
+		return nil, fmt.Errorf("ring is empty")
+	}
+	b.Ring.RLock()
+	defer b.Ring.RUnlock()
+	return putAt(order, 0, b.Ring.replicationFactor, b.Ring.isUnavailable)
+}
+
+func (b *BoundedLoad) Get(key string) (string, error) {
+	order := b.order(key)
+	if len(order) == 0 {
+		return "", fmt.Errorf("ring is empty")
+	}
+	return order[0].Server, nil
+}
+
+func (b *BoundedLoad) GetNodeAndReplicas(key string) ([]string, error) {
+	order := b.order(key)
+	if len(order) == 0 {
+		return nil, fmt.Errorf("ring is empty")
+	}
+	b.Ring.RLock()
+	defer b.Ring.RUnlock()
+	return serversAt(order, 0, b.Ring.replicationFactor, b.Ring.isUnavailable), nil
+}
+
+func (b *BoundedLoad) PreferenceList(key string) ([]string, error) {
+	order := b.order(key)
+	if len(order) == 0 {
+		return nil, fmt.Errorf("ring is empty")
+	}
+	b.Ring.RLock()
+	defer b.Ring.RUnlock()
+	return serversAt(order, 0, b.Ring.replicationFactor, nil), nil
+}
+
+func (b *BoundedLoad) HandoffCandidates(key string) ([]string, error) {
+	order := b.order(key)
+	if len(order) == 0 {
+		return nil, fmt.Errorf("ring is empty")
+	}
+	b.Ring.RLock()
+	defer b.Ring.RUnlock()
+	return handoffAt(order, 0, b.Ring.replicationFactor, b.Ring.isUnavailable), nil
+}
+
+func (b *BoundedLoad) StandIns(key string) map[string]string {
+	order := b.order(key)
+	b.Ring.RLock()
+	defer b.Ring.RUnlock()
+	if len(order) == 0 {
+		return make(map[string]string)
+	}
+	return standInsAt(order, 0, b.Ring.replicationFactor, b.Ring.isUnavailable)
+}
+
+// Partitions returns the ranges of the ring with their assigned owners.
+func (b *BoundedLoad) Partitions() ([]Partition, uint64) {
+	b.Ring.RLock()
+	defer b.Ring.RUnlock()
+	partitions := partitionsOf(b.Ring.Nodes, b.Ring.replicationFactor)
+	for i := range partitions {
+		partitions[i].Nodes = neighbors(ownersAt(b.orders[i], 0, b.Ring.replicationFactor, nil))
+	}
+	return partitions, b.Ring.epoch
+}
+
+func (b *BoundedLoad) Plan(change Change) ([]Transfer, error) {
+	return planChange(b, func() Partitioner { return NewBoundedLoad(b.Ring.config(), b.Balance) }, change)
+}
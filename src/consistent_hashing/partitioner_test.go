@@ -0,0 +1,161 @@
+package consistent
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+)
+
+var _ Partitioner = (*Ring)(nil)
+var _ Partitioner = (*Rendezvous)(nil)
+var _ Partitioner = (*Jump)(nil)
+var _ Partitioner = (*BoundedLoad)(nil)
+
+func testMembers(count int) []Member {
+	var members []Member
+	for i := 0; i < count; i++ {
+		members = append(members, Member{ID: fmt.Sprintf("s%02d", i), Server: fmt.Sprintf("localhost:%d", 9000+i), VirtualNodes: 3})
+	}
+	return members
+}
+
+func TestPartitionersChooseDistinctServers(t *testing.T) {
+	for _, name := range []string{"ring", "rendezvous", "jump", "bounded"} {
+		partitioner, err := NewPartitioner(name, DefaultRingConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		partitioner.Restore(testMembers(5), 1)
+		for i := 0; i < 100; i++ {
+			email := fmt.Sprintf("user%d@example.com", i)
+			servers, err := partitioner.Put(email)
+			if err != nil {
+				t.Fatal(err)
+			}
+			distinct := make(map[string]bool)
+			for _, server := range servers {
+				distinct[server] = true
+			}
+			if len(servers) != 3 || len(distinct) != 3 {
+				t.Fatalf("%s: got servers %v", name, servers)
+			}
+			again, _ := partitioner.GetNodeAndReplicas(email)
+			if fmt.Sprint(again) != fmt.Sprint(servers) {
+				t.Fatalf("%s: %s moved from %v to %v", name, email, servers, again)
+			}
+			owner, _ := partitioner.Get(email)
+			if owner != servers[0] {
+				t.Fatalf("%s: owner %s is not the first of %v", name, owner, servers)
+			}
+		}
+		// an unavailable owner is stood in for by the next server
+		owner, _ := partitioner.Get("user0@example.com")
+		for _, member := range testMembers(5) {
+			if member.Server == owner {
+				partitioner.SetAvailable(member.ID, false)
+			}
+		}
+		standIns := partitioner.StandIns("user0@example.com")
+		if len(standIns) != 1 {
+			t.Errorf("%s: got stand-ins %v for %s", name, standIns, owner)
+		}
+	}
+}
+
+func TestRendezvousOnlyMovesTheKeysOfARemovedServer(t *testing.T) {
+	rendezvous := NewRendezvous(DefaultRingConfig())
+	members := testMembers(6)
+	rendezvous.Restore(members, 1)
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		before[email], _ = rendezvous.Get(email)
+	}
+	rendezvous.Restore(members[1:], 2)
+	for email, owner := range before {
+		after, _ := rendezvous.Get(email)
+		if owner != members[0].Server && after != owner {
+			t.Fatalf("%s moved from %s to %s", email, owner, after)
+		}
+	}
+}
+
+func TestPartitionsPlaceKeysLikeTheLookups(t *testing.T) {
+	for _, name := range []string{"ring", "rendezvous", "jump", "bounded"} {
+		partitioner, _ := NewPartitioner(name, DefaultRingConfig())
+		partitioner.Restore(testMembers(5), 1)
+		partitions, epoch := partitioner.Partitions()
+		if epoch != 1 || len(partitions) < 5 {
+			t.Fatalf("%s: %d partitions at epoch %d", name, len(partitions), epoch)
+		}
+		for i, partition := range partitions {
+			previous := partitions[(i+len(partitions)-1)%len(partitions)]
+			if !bytes.Equal(partition.Range.Start, previous.Range.End) {
+				t.Fatalf("%s: partition %d does not start where the one before ends", name, i)
+			}
+		}
+		for i := 0; i < 500; i++ {
+			email := fmt.Sprintf("user%d@example.com", i)
+			hash := partitioner.Hasher().Hash([]byte(email))
+			var placed []string
+			for _, node := range partitions[partitionAt(partitions, hash)].Nodes {
+				placed = append(placed, node.Server)
+			}
+			servers, _ := partitioner.PreferenceList(email)
+			if fmt.Sprint(placed) != fmt.Sprint(servers) {
+				t.Fatalf("%s: %s is on %v, its partition on %v", name, email, servers, placed)
+			}
+		}
+	}
+}
+
+func TestPlansOfEveryPartitionerMoveTheKeys(t *testing.T) {
+	for _, name := range []string{"ring", "rendezvous", "jump", "bounded"} {
+		partitioner, _ := NewPartitioner(name, DefaultRingConfig())
+		members := testMembers(6)
+		partitioner.Restore(members, 1)
+		added := Member{ID: "s99", Server: "localhost:9099", VirtualNodes: 3}
+		transfers, err := partitioner.Plan(Change{Add: []Member{added}, Remove: []string{members[2].ID}})
+		if err != nil || len(transfers) == 0 {
+			t.Fatalf("%s: planned %d transfers: %v", name, len(transfers), err)
+		}
+		changed := append(append([]Member{}, members[:2]...), members[3:]...)
+		checkTransfers(t, partitioner, transfers, func() {
+			partitioner.Restore(append(changed, added), 2)
+		})
+	}
+}
+
+func TestBoundedLoadCapsTheShareOfAServer(t *testing.T) {
+	bounded := NewBoundedLoad(DefaultRingConfig(), DefaultBalance)
+	members := testMembers(4)
+	bounded.Restore(members, 1)
+	partitions, _ := bounded.Partitions()
+	owned := make(map[string]float64)
+	for _, partition := range partitions {
+		width := float64(uint64Of(partition.Range.End)-uint64Of(partition.Range.Start)) / math.Pow(2, 64)
+		owned[partition.Nodes[0].Server] += width
+	}
+	for server, share := range owned {
+		if share > DefaultBalance/float64(len(members)) {
+			t.Errorf("%s owns %.2f of the hash space, more than %.2f", server, share, DefaultBalance/float64(len(members)))
+		}
+	}
+}
+
+func TestBoundedLoadOnlyDependsOnTheMembers(t *testing.T) {
+	members := testMembers(4)
+	bounded := NewBoundedLoad(DefaultRingConfig(), DefaultBalance)
+	bounded.Restore(members, 1)
+	reversed := NewBoundedLoad(DefaultRingConfig(), DefaultBalance)
+	reversed.Restore([]Member{members[3], members[2], members[1], members[0]}, 1)
+	for i := 0; i < 1000; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		put, _ := bounded.Put(email)
+		got, _ := reversed.GetNodeAndReplicas(email)
+		if fmt.Sprint(put) != fmt.Sprint(got) {
+			t.Fatalf("%s is on %v and on %v", email, put, got)
+		}
+	}
+}
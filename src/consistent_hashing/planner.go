@@ -0,0 +1,57 @@
+package consistent
+
+import "fmt"
+
+// Change is a proposed change of the members of a partitioner: members to add and ids of
+// members to remove. An added member already there replaces it, to plan a change of its
+// weight or zone.
+type Change struct {
+	Add    []Member
+	Remove []string
+}
+
+func (r *Ring) Plan(change Change) ([]Transfer, error) {
+	return planChange(r, func() Partitioner { return NewRing(r.config()) }, change)
+}
+
+// planChange places the members of p and the members after change with two new partitioners
+// made by fresh, and compares their partitions.
+func planChange(p Partitioner, fresh func() Partitioner, change Change) ([]Transfer, error) {
+	members, epoch := p.Members()
+	present := make(map[string]bool)
+	for _, member := range members {
+		present[member.ID] = true
+	}
+
+	removed := make(map[string]bool)
+	for _, id := range change.Remove {
+		if !present[id] {
+			return nil, fmt.Errorf("node %s is not in the ring", id)
+		}
+		removed[id] = true
+	}
+	for _, member := range change.Add {
+		if removed[member.ID] {
+			return nil, fmt.Errorf("node %s is both added and removed", member.ID)
+		}
+		removed[member.ID] = true
+	}
+	var changed []Member
+	for _, member := range members {
+		if !removed[member.ID] {
+			changed = append(changed, member)
+		}
+	}
+	changed = append(changed, change.Add...)
+	if len(changed) == 0 {
+		return nil, fmt.Errorf("no node would be left in the ring")
+	}
+
+	before := fresh()
+	before.Restore(members, epoch)
+	after := fresh()
+	after.Restore(changed, epoch+1)
+	beforePartitions, _ := before.Partitions()
+	afterPartitions, _ := after.Partitions()
+	return OwnerChanges(beforePartitions, afterPartitions), nil
+}
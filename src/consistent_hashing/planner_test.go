@@ -0,0 +1,30 @@
+package consistent
+
+import "testing"
+
+func TestPlanAddingAndRemovingAtOnce(t *testing.T) {
+	ring := testRing()
+	moves, err := ring.Plan(Change{Add: []Member{{ID: "z", Server: "server-z", VirtualNodes: 3}}, Remove: []string{"b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTransfers(t, ring, moves, func() {
+		ring.RemoveNode("b")
+		ring.AddNode("z", "server-z")
+	})
+}
+
+func TestPlanLeavesTheRingAlone(t *testing.T) {
+	ring := testRing()
+	before, epoch := ring.Members()
+	if _, err := ring.Plan(Change{Remove: []string{"a", "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	after, afterEpoch := ring.Members()
+	if len(after) != len(before) || afterEpoch != epoch {
+		t.Errorf("planning changed the ring")
+	}
+	if _, err := ring.Plan(Change{Remove: []string{"unknown"}}); err == nil {
+		t.Error("removing an unknown node was planned")
+	}
+}
@@ -0,0 +1,75 @@
+package crdt
+
+import (
+	"CloudShoppingList/causalcontext"
+)
+
+// Delta returns what a replica with causal context since needs to catch up with list.
+// Joining the delta into that replica gives the same result as joining the whole list.
+// A nil context yields the full state.
+//
+// Removals are not logged: a dot that the context of a list covers but the list does not
+// hold was removed. A delta carries the dots since has not seen with the whole context of
+// list, and lists in Alive the dots since has seen that are still there, so the receiver
+// drops exactly the dots list removed. A context that covers every dot of list gets an
+// empty delta.
+func (list *List) Delta(since *causalcontext.CausalContext) *List {
+	delta := &List{
+		Data:      make(map[string]*DotStore),
+		Cc:        causalcontext.NewCausalContext(nil),
+		ReplicaID: list.ReplicaID,
+	}
+	if since != nil && covers(since, list.Cc) {
+		return delta
+	}
+
+	alive := make(map[Dot]bool)
+	for key, dotStore := range list.Data {
+		for dot, counter := range dotStore.Data {
+			if since != nil && since.DotIn(causalcontext.Pair{Key: dot.ReplicaID, Value: dot.Counter}) {
+				alive[dot] = true
+				continue
+			}
+			if delta.Data[key] == nil {
+				delta.Data[key] = &DotStore{Data: make(map[Dot]Counter)}
+			}
+			delta.Data[key].add(dot, counter)
+		}
+	}
+	delta.Alive = aliveCounters(alive)
+	delta.Cc.Join(list.Cc)
+	return delta
+}
+
+// covers reports whether since has seen every dot of cc.
+func covers(since, cc *causalcontext.CausalContext) bool {
+	for replica, current := range cc.Cc {
+		for counter := since.Current(replica) + 1; counter <= current; counter++ {
+			if !since.DotIn(causalcontext.Pair{Key: replica, Value: counter}) {
+				return false
+			}
+		}
+	}
+	if cc.Dc != nil {
+		for _, dot := range cc.Dc.Values() {
+			if !since.DotIn(causalcontext.Pair{Key: dot.Key, Value: dot.Value}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// IsEmpty reports whether the list holds no items and no dots in its context, which is
+// the case for a delta against a context that already covers everything.
+func (list *List) IsEmpty() bool {
+	if len(list.Data) != 0 || (list.Cc.Dc != nil && len(list.Cc.Dc.Values()) != 0) {
+		return false
+	}
+	for _, current := range list.Cc.Cc {
+		if current != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -3,10 +3,12 @@ package crdt
 import (
 	"CloudShoppingList/causalcontext"
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/gob"
 	"fmt"
 	"os"
+	"sort"
 )
 
 // ORMap represents an Observed-Remove Map.
@@ -14,6 +16,9 @@ type List struct {
 	Data      map[string]*DotStore
 	Cc        *causalcontext.CausalContext
 	ReplicaID string
+	// Alive lists, by replica, the counters of the dots a delta covers without carrying them:
+	// the receiver has them already and they are not removed. A full state has none.
+	Alive map[string][]int
 }
 
 type DotStore struct {
@@ -61,34 +66,36 @@ func (list *List) Decrement(key string) {
 	list.Data[key].update(list.ReplicaID, Counter{Positive: 0, Negative: 1}, list.Cc)
 }
 
+// Remove drops every dot of key that this replica has observed. The dots stay
+// recorded in the causal context so that Join treats them as removed, while
+// increments made concurrently on other replicas (new dots) still survive.
 func (list *List) Remove(key string) {
+	dotStore, exists := list.Data[key]
+	if !exists {
+		return
+	}
+	for dot := range dotStore.Data {
+		list.Cc.InsertDot(dot.ReplicaID, dot.Counter, false)
+	}
+	list.Cc.Compact()
 	delete(list.Data, key)
+
+	// the removal takes a dot of its own, so a replica that has not seen it gets a delta
+	list.Cc.MakeDot(list.ReplicaID)
 }
 
+// update replaces the dot of replicaID with a fresh dot carrying its counter plus change,
+// so a replica keeps a single dot per item however many times it changes it. The old dot
+// stays in the causal context, which makes Join discard it on the other replicas.
 func (DotStore *DotStore) update(replicaID string, change Counter, cc *causalcontext.CausalContext) {
-	version := -1
-	for dot := range DotStore.Data {
+	for dot, counter := range DotStore.Data {
 		if dot.ReplicaID == replicaID {
-			version = dot.Counter
+			change = Counter{Positive: counter.Positive + change.Positive, Negative: counter.Negative + change.Negative}
+			delete(DotStore.Data, dot)
 		}
 	}
-	currentCasualContexValue := cc.Current(replicaID)
-
-	if currentCasualContexValue != version {
-		DotStore.fresh(replicaID, cc)
-		currentCasualContexValue = cc.Current(replicaID)
-	}
-
-	counter := DotStore.Data[Dot{ReplicaID: replicaID, Counter: currentCasualContexValue}]
-	counter.Positive += change.Positive
-	counter.Negative += change.Negative
-	DotStore.Data[Dot{ReplicaID: replicaID, Counter: currentCasualContexValue}] = counter
-}
-
-func (DotStore *DotStore) fresh(replicaID string, cc *causalcontext.CausalContext) {
-	pair := cc.Next(replicaID)
-	DotStore.Data[Dot{ReplicaID: pair.Key, Counter: pair.Value}] = Counter{Positive: 0, Negative: 0}
-	cc.MakeDot(pair.Key)
+	pair := cc.MakeDot(replicaID)
+	DotStore.Data[Dot{ReplicaID: pair.Key, Counter: pair.Value}] = change
 }
 
 func (DotStore *DotStore) Value() int {
@@ -102,50 +109,96 @@ func (DotStore *DotStore) Value() int {
 	return value
 }
 
+// Join merges other into list with observed-remove, add-wins semantics:
+// a dot present on only one side survives unless the other side has already
+// seen it in its causal context, which means it was removed there.
 func (list *List) Join(other *List) {
-	originalData := make(map[string]*DotStore)
-	for key, dotStore := range list.Data {
-		newDotStore := &DotStore{Data: make(map[Dot]Counter)}
-		for dot, counter := range dotStore.Data {
-			newDotStore.Data[dot] = counter
+	listLive, otherLive := list.liveDots(), other.liveDots()
+	for key, otherDotStore := range other.Data {
+		dotStore, exists := list.Data[key]
+		if !exists {
+			dotStore = &DotStore{Data: make(map[Dot]Counter)}
 		}
-		originalData[key] = newDotStore
-	}
-
-	for key, dotStore := range other.Data {
-		if _, exists := list.Data[key]; exists {
-			for dot, counter := range dotStore.Data {
-				if _, exists := list.Data[key].Data[dot]; exists {
-					list.Data[key].Data[dot] = max(list.Data[key].Data[dot], counter)
-				} else {
-					if dot.Counter > list.Cc.Current(dot.ReplicaID) {
-						list.Data[key].add(dot, counter)
-					}
-				}
+		for dot, counter := range otherDotStore.Data {
+			if current, exists := dotStore.Data[dot]; exists {
+				dotStore.Data[dot] = max(current, counter)
+			} else if listLive[dot] || !list.Cc.DotIn(causalcontext.Pair{Key: dot.ReplicaID, Value: dot.Counter}) {
+				dotStore.add(dot, counter)
 			}
-		} else {
-			newDotStore := &DotStore{Data: make(map[Dot]Counter)}
-			for dot, counter := range dotStore.Data {
-				newDotStore.Data[dot] = counter
-			}
-			list.Data[key] = newDotStore
+		}
+		if len(dotStore.Data) != 0 {
+			list.Data[key] = dotStore
 		}
 	}
 
-	for key, dotStore := range originalData {
-		if _, exists := other.Data[key]; !exists {
-			for dot := range dotStore.Data {
-				if dot.Counter <= other.Cc.Current(dot.ReplicaID) {
-					delete(list.Data, key)
-				}
+	for key, dotStore := range list.Data {
+		for dot := range dotStore.Data {
+			if !otherLive[dot] && other.Cc.DotIn(causalcontext.Pair{Key: dot.ReplicaID, Value: dot.Counter}) {
+				dotStore.remove(dot)
 			}
 		}
+		if len(dotStore.Data) == 0 {
+			delete(list.Data, key)
+		}
+	}
+
+	// joining deltas keeps the dots still alive on both sides that neither carries
+	alive := make(map[Dot]bool)
+	for dot := range list.aliveDots() {
+		if otherLive[dot] || !other.Cc.DotIn(causalcontext.Pair{Key: dot.ReplicaID, Value: dot.Counter}) {
+			alive[dot] = true
+		}
+	}
+	for dot := range other.aliveDots() {
+		if listLive[dot] || !list.Cc.DotIn(causalcontext.Pair{Key: dot.ReplicaID, Value: dot.Counter}) {
+			alive[dot] = true
+		}
 	}
+	for _, dotStore := range list.Data {
+		for dot := range dotStore.Data {
+			delete(alive, dot)
+		}
+	}
+	list.Alive = aliveCounters(alive)
 
 	list.Cc.Join(other.Cc)
-	for _, dotStore := range other.Data {
-		dotStore.fresh(other.ReplicaID, other.Cc)
+}
+
+// aliveDots returns the dots of Alive.
+func (list *List) aliveDots() map[Dot]bool {
+	dots := make(map[Dot]bool)
+	for replica, counters := range list.Alive {
+		for _, counter := range counters {
+			dots[Dot{ReplicaID: replica, Counter: counter}] = true
+		}
+	}
+	return dots
+}
+
+// liveDots returns the dots the list holds and the dots it lists as alive.
+func (list *List) liveDots() map[Dot]bool {
+	dots := list.aliveDots()
+	for _, dotStore := range list.Data {
+		for dot := range dotStore.Data {
+			dots[dot] = true
+		}
+	}
+	return dots
+}
+
+// aliveCounters groups dots by replica in the form of Alive, nil when there are none.
+func aliveCounters(dots map[Dot]bool) map[string][]int {
+	if len(dots) == 0 {
+		return nil
+	}
+	counters := make(map[string][]int)
+	for dot := range dots {
+		counters[dot.ReplicaID] = append(counters[dot.ReplicaID], dot.Counter)
+	}
+	for _, replicaCounters := range counters {
+		sort.Ints(replicaCounters)
 	}
+	return counters
 }
 
 func (DotStore *DotStore) GetDot() {
@@ -156,7 +209,19 @@ func (DotStore *DotStore) GetDot() {
 	}
 }
 
+// add keeps dot unless the store holds a newer dot of the same replica. A newer dot of a
+// replica replaces its older ones, which a delta without the older dots in its context
+// could not discard otherwise.
 func (DotStore *DotStore) add(dot Dot, counter Counter) {
+	for current := range DotStore.Data {
+		if current.ReplicaID != dot.ReplicaID {
+			continue
+		}
+		if current.Counter > dot.Counter {
+			return
+		}
+		delete(DotStore.Data, current)
+	}
 	DotStore.Data[dot] = counter
 }
 
@@ -164,6 +229,61 @@ func (DotStore *DotStore) remove(dot Dot) {
 	delete(DotStore.Data, dot)
 }
 
+// Digest hashes a canonical encoding of the list, so replicas holding the same
+// state get the same digest regardless of map order or replica id.
+func (list *List) Digest() []byte {
+	h := sha256.New()
+
+	keys := make([]string, 0, len(list.Data))
+	for key := range list.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "item %q\n", key)
+		dotStore := list.Data[key]
+		for _, dot := range sortedDots(dotStore.Data) {
+			counter := dotStore.Data[dot]
+			fmt.Fprintf(h, "%q %d %d %d\n", dot.ReplicaID, dot.Counter, counter.Positive, counter.Negative)
+		}
+	}
+
+	replicas := make([]string, 0, len(list.Cc.Cc))
+	for replica, value := range list.Cc.Cc {
+		if value != 0 {
+			replicas = append(replicas, replica)
+		}
+	}
+	sort.Strings(replicas)
+	for _, replica := range replicas {
+		fmt.Fprintf(h, "context %q %d\n", replica, list.Cc.Cc[replica])
+	}
+	if list.Cc.Dc != nil {
+		cloud := make(map[Dot]Counter)
+		for _, pair := range list.Cc.Dc.Values() {
+			cloud[Dot{ReplicaID: pair.Key, Counter: pair.Value}] = Counter{}
+		}
+		for _, dot := range sortedDots(cloud) {
+			fmt.Fprintf(h, "cloud %q %d\n", dot.ReplicaID, dot.Counter)
+		}
+	}
+	return h.Sum(nil)
+}
+
+func sortedDots(dots map[Dot]Counter) []Dot {
+	sorted := make([]Dot, 0, len(dots))
+	for dot := range dots {
+		sorted = append(sorted, dot)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].ReplicaID != sorted[j].ReplicaID {
+			return sorted[i].ReplicaID < sorted[j].ReplicaID
+		}
+		return sorted[i].Counter < sorted[j].Counter
+	})
+	return sorted
+}
+
 func FromGOB64(s string) *List {
 	list := &List{}
 	data, err := base64.StdEncoding.DecodeString(s)
@@ -177,6 +297,21 @@ func FromGOB64(s string) *List {
 	if err != nil {
 		fmt.Println("Error decoding list:", err)
 	}
+	// gob leaves empty maps as nil, restore them so the list can be mutated
+	if list.Data == nil {
+		list.Data = make(map[string]*DotStore)
+	}
+	for _, dotStore := range list.Data {
+		if dotStore.Data == nil {
+			dotStore.Data = make(map[Dot]Counter)
+		}
+	}
+	if list.Cc == nil {
+		list.Cc = causalcontext.NewCausalContext(nil)
+	}
+	if list.Cc.Cc == nil {
+		list.Cc.Cc = make(map[string]int)
+	}
 	return list
 }
 
@@ -0,0 +1,353 @@
+package crdt
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// copyList simulates shipping a replica over the network.
+func copyList(list *List) *List {
+	return FromGOB64(list.ToGOB64())
+}
+
+// sync exchanges state between two replicas in both directions.
+func sync(a, b *List) {
+	aState := copyList(a)
+	bState := copyList(b)
+	a.Join(bState)
+	b.Join(aState)
+}
+
+func values(list *List) map[string]int {
+	result := make(map[string]int)
+	for key, dotStore := range list.Data {
+		result[key] = dotStore.Value()
+	}
+	return result
+}
+
+func assertConverged(t *testing.T, want map[string]int, lists ...*List) {
+	t.Helper()
+	for _, list := range lists {
+		if got := values(list); !reflect.DeepEqual(got, want) {
+			t.Errorf("replica %s: got %v, want %v", list.ReplicaID, got, want)
+		}
+	}
+}
+
+func TestRemoveIsPropagated(t *testing.T) {
+	a, b, c := NewList("a"), NewList("b"), NewList("c")
+	a.Increment("milk")
+	a.Increment("milk")
+	a.Increment("eggs")
+	sync(a, b)
+	sync(b, c)
+
+	b.Remove("milk")
+	sync(b, c)
+	sync(c, a)
+
+	assertConverged(t, map[string]int{"eggs": 1}, a, b, c)
+}
+
+func TestStaleReplicaDoesNotResurrectRemovedItem(t *testing.T) {
+	a, b, c := NewList("a"), NewList("b"), NewList("c")
+	a.Increment("milk")
+	sync(a, b)
+	sync(a, c)
+
+	a.Remove("milk")
+	// c still holds the old milk dot and pushes it back to a
+	a.Join(copyList(c))
+	b.Join(copyList(a))
+	c.Join(copyList(b))
+
+	assertConverged(t, map[string]int{}, a, b, c)
+}
+
+func TestConcurrentAddWinsOverRemove(t *testing.T) {
+	a, b, c := NewList("a"), NewList("b"), NewList("c")
+	a.Increment("milk")
+	a.Increment("milk")
+	sync(a, b)
+	sync(a, c)
+
+	// b removes the milk it has seen while c concurrently adds one more
+	b.Remove("milk")
+	c.Increment("milk")
+
+	sync(b, c)
+	sync(a, b)
+	sync(a, c)
+
+	assertConverged(t, map[string]int{"milk": 1}, a, b, c)
+}
+
+func TestConcurrentRemoveAndReAddOnSameReplica(t *testing.T) {
+	a, b, c := NewList("a"), NewList("b"), NewList("c")
+	a.Increment("bread")
+	sync(a, b)
+	sync(a, c)
+
+	// a removes and re-adds while b removes concurrently
+	a.Remove("bread")
+	a.Increment("bread")
+	a.Increment("bread")
+	b.Remove("bread")
+	c.Decrement("bread")
+
+	sync(a, b)
+	sync(b, c)
+	sync(c, a)
+	sync(a, b)
+
+	assertConverged(t, map[string]int{"bread": 1}, a, b, c)
+}
+
+func TestJoinIsOrderIndependent(t *testing.T) {
+	build := func() (*List, *List, *List) {
+		a, b, c := NewList("a"), NewList("b"), NewList("c")
+		a.Increment("rice")
+		a.Increment("pasta")
+		sync(a, b)
+		sync(a, c)
+		a.Remove("rice")
+		b.Increment("rice")
+		c.Remove("pasta")
+		c.Increment("beans")
+		return a, b, c
+	}
+
+	a1, b1, c1 := build()
+	target1 := NewList("x")
+	target1.Join(copyList(a1))
+	target1.Join(copyList(b1))
+	target1.Join(copyList(c1))
+
+	a2, b2, c2 := build()
+	target2 := NewList("y")
+	target2.Join(copyList(c2))
+	target2.Join(copyList(b2))
+	target2.Join(copyList(a2))
+
+	if !reflect.DeepEqual(values(target1), values(target2)) {
+		t.Fatalf("join order changed the result: %v vs %v", values(target1), values(target2))
+	}
+	assertConverged(t, map[string]int{"rice": 1, "beans": 1}, target1, target2)
+}
+
+func TestRandomRemoveAddRacesConverge(t *testing.T) {
+	items := []string{"milk", "eggs", "bread"}
+	random := rand.New(rand.NewSource(42))
+	for round := 0; round < 50; round++ {
+		replicas := []*List{NewList("a"), NewList("b"), NewList("c")}
+		for step := 0; step < 40; step++ {
+			replica := replicas[random.Intn(len(replicas))]
+			item := items[random.Intn(len(items))]
+			switch random.Intn(4) {
+			case 0, 1:
+				replica.Increment(item)
+			case 2:
+				replica.Decrement(item)
+			case 3:
+				replica.Remove(item)
+			}
+			if random.Intn(3) == 0 {
+				i, j := random.Intn(len(replicas)), random.Intn(len(replicas))
+				if i != j {
+					replicas[i].Join(copyList(replicas[j]))
+				}
+			}
+		}
+		sync(replicas[0], replicas[1])
+		sync(replicas[1], replicas[2])
+		sync(replicas[2], replicas[0])
+
+		assertConverged(t, values(replicas[0]), replicas...)
+	}
+}
+
+func TestDeltaMatchesFullStateJoin(t *testing.T) {
+	a, b := NewList("a"), NewList("b")
+	a.Increment("milk")
+	a.Increment("eggs")
+	a.Increment("rice")
+	sync(a, b)
+
+	// b only knows a's context from before these mutations
+	since := copyList(b).Cc
+	a.Remove("milk")
+	a.Increment("eggs")
+	a.Decrement("rice")
+	a.Increment("bread")
+
+	delta := copyList(a.Delta(since))
+	viaDelta := copyList(b)
+	viaDelta.Join(delta)
+	viaState := copyList(b)
+	viaState.Join(copyList(a))
+
+	assertConverged(t, values(viaState), viaDelta)
+	assertConverged(t, map[string]int{"eggs": 2, "rice": 0, "bread": 1}, viaDelta)
+}
+
+func TestDeltaAgainstCurrentContextIsEmpty(t *testing.T) {
+	a := NewList("a")
+	a.Increment("milk")
+	a.Remove("milk")
+	a.Increment("eggs")
+
+	if delta := a.Delta(copyList(a).Cc); !delta.IsEmpty() {
+		t.Fatalf("expected an empty delta, got %v", values(delta))
+	}
+	if delta := a.Delta(nil); len(delta.Data) != len(a.Data) || !reflect.DeepEqual(delta.Cc.Cc, a.Cc.Cc) {
+		t.Fatalf("delta against no context should carry the full state")
+	}
+}
+
+func TestDeltaKeepsConcurrentAdds(t *testing.T) {
+	a, b, c := NewList("a"), NewList("b"), NewList("c")
+	a.Increment("milk")
+	sync(a, b)
+	sync(a, c)
+
+	since := copyList(c).Cc
+	b.Remove("milk")
+	c.Increment("milk")
+
+	// c receives only b's removal, a receives both deltas
+	c.Join(copyList(b.Delta(since)))
+	a.Join(copyList(b.Delta(since)))
+	a.Join(copyList(c.Delta(since)))
+	b.Join(copyList(c.Delta(since)))
+
+	assertConverged(t, map[string]int{"milk": 1}, a, b, c)
+}
+
+func TestDigestIsEqualForConvergedReplicas(t *testing.T) {
+	a, b := NewList("a"), NewList("b")
+	a.Increment("milk")
+	a.Increment("eggs")
+	b.Increment("rice")
+	b.Remove("rice")
+	if bytes.Equal(a.Digest(), b.Digest()) {
+		t.Fatalf("different states have the same digest")
+	}
+
+	sync(a, b)
+	if !bytes.Equal(a.Digest(), b.Digest()) {
+		t.Fatalf("converged replicas have different digests")
+	}
+	if !bytes.Equal(a.Digest(), copyList(a).Digest()) {
+		t.Fatalf("encoding the list changed its digest")
+	}
+}
+
+func TestMutationsKeepOneDotPerReplica(t *testing.T) {
+	a, b := NewList("a"), NewList("b")
+	for i := 0; i < 100; i++ {
+		a.Increment("milk")
+	}
+	a.Decrement("milk")
+	sync(a, b)
+	b.Increment("milk")
+	sync(a, b)
+
+	for _, list := range []*List{a, b} {
+		if dots := len(list.Data["milk"].Data); dots != 2 {
+			t.Errorf("replica %s keeps %d milk dots, want one per replica", list.ReplicaID, dots)
+		}
+	}
+	assertConverged(t, map[string]int{"milk": 100}, a, b)
+}
+
+func TestOlderDotDoesNotComeBackAfterDelta(t *testing.T) {
+	a, b, c := NewList("a"), NewList("b"), NewList("c")
+	a.Increment("milk")
+	sync(a, c)
+
+	// b only learns the replacing dot through a delta, c still holds the old one
+	since := copyList(b).Cc
+	a.Increment("milk")
+	b.Join(copyList(a.Delta(since)))
+	b.Join(copyList(c))
+
+	assertConverged(t, map[string]int{"milk": 2}, a, b)
+	if dots := len(b.Data["milk"].Data); dots != 1 {
+		t.Errorf("b keeps %d milk dots, want 1", dots)
+	}
+}
+
+func TestRemovalsKeepNoHistory(t *testing.T) {
+	a, b := NewList("a"), NewList("b")
+	cycle := func(times int) {
+		for i := 0; i < times; i++ {
+			a.Increment("milk")
+			a.Remove("milk")
+			sync(a, b)
+		}
+	}
+	cycle(10)
+	before := len(a.ToGOB64())
+	cycle(1000)
+	// only the counters of the context grow, by a few bytes
+	if after := len(a.ToGOB64()); after > before+8 {
+		t.Fatalf("the encoded list grew from %d to %d bytes with removals", before, after)
+	}
+
+	since := copyList(b).Cc
+	a.Increment("eggs")
+	b.Join(copyList(a.Delta(since)))
+	assertConverged(t, map[string]int{"eggs": 1}, a, b)
+	if !bytes.Equal(a.Digest(), b.Digest()) {
+		t.Fatalf("replicas joined by a delta have different digests")
+	}
+}
+
+func TestDeltaOfOneChangeCarriesOnlyThatItem(t *testing.T) {
+	a, b := NewList("a"), NewList("b")
+	for i := 0; i < 500; i++ {
+		a.Increment("item" + strconv.Itoa(i))
+	}
+	sync(a, b)
+
+	since := copyList(b).Cc
+	a.Increment("item7")
+	delta := a.Delta(since)
+	if len(delta.Data) != 1 || delta.Data["item7"] == nil {
+		t.Fatalf("delta carries %d items, want only item7", len(delta.Data))
+	}
+	full, encoded := len(a.ToGOB64()), len(delta.ToGOB64())
+	if encoded >= full/2 {
+		t.Fatalf("delta takes %d bytes, the full state %d", encoded, full)
+	}
+
+	b.Join(copyList(delta))
+	if !bytes.Equal(a.Digest(), b.Digest()) {
+		t.Fatalf("joining the delta did not converge")
+	}
+}
+
+func TestJoinedDeltasKeepTheDotsAlive(t *testing.T) {
+	a, b := NewList("a"), NewList("b")
+	a.Increment("milk")
+	a.Increment("eggs")
+	a.Increment("rice")
+	sync(a, b)
+
+	// two deltas against the same context joined on the way, the way hints are
+	since := copyList(b).Cc
+	a.Increment("milk")
+	first := copyList(a.Delta(since))
+	a.Remove("rice")
+	a.Increment("bread")
+	joined := copyList(first)
+	joined.Join(copyList(a.Delta(since)))
+
+	b.Join(copyList(joined))
+	assertConverged(t, values(a), b)
+	assertConverged(t, map[string]int{"milk": 2, "eggs": 1, "bread": 1}, b)
+}
@@ -22,6 +22,9 @@ func NewCustomSet() *DotCloud {
 func (cs *DotCloud) Add(key string, value int) {
 	pair := Pair{Key: key, Value: value}
 	keyStr := KeyFor(pair)
+	if cs.Refs == nil {
+		cs.Refs = make(map[string]Pair)
+	}
 	if _, exists := cs.Refs[keyStr]; !exists {
 		cs.Refs[keyStr] = pair
 	}
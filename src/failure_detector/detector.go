@@ -0,0 +1,132 @@
+package detector
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the health of a node as seen by the failure detector.
+type State int
+
+const (
+	Alive State = iota
+	Suspect
+	Dead
+)
+
+func (s State) String() string {
+	switch s {
+	case Alive:
+		return "alive"
+	case Suspect:
+		return "suspect"
+	case Dead:
+		return "dead"
+	}
+	return "unknown"
+}
+
+// Detector is a timeout based failure detector. A node that has not been heard
+// from for suspectAfter becomes suspect, and after deadAfter it is declared dead.
+// Any heartbeat brings it back to alive.
+type Detector struct {
+	sync.RWMutex
+	suspectAfter time.Duration
+	deadAfter    time.Duration
+	lastSeen     map[string]time.Time
+	states       map[string]State
+	// OnChange is called, without the lock held, every time a node changes state
+	OnChange func(id string, state State)
+}
+
+func NewDetector(suspectAfter, deadAfter time.Duration) *Detector {
+	return &Detector{
+		suspectAfter: suspectAfter,
+		deadAfter:    deadAfter,
+		lastSeen:     make(map[string]time.Time),
+		states:       make(map[string]State),
+	}
+}
+
+// Add starts monitoring a node, which is considered alive until it misses heartbeats.
+func (d *Detector) Add(id string) {
+	d.Heartbeat(id)
+}
+
+// AddUnverified starts monitoring a node that has not answered yet, such as one remembered from
+// before a restart. It is suspect until its first heartbeat, and dead when none comes.
+func (d *Detector) AddUnverified(id string) {
+	d.Lock()
+	defer d.Unlock()
+	d.lastSeen[id] = time.Now().Add(-d.suspectAfter)
+	d.states[id] = Suspect
+}
+
+func (d *Detector) Remove(id string) {
+	d.Lock()
+	defer d.Unlock()
+	delete(d.lastSeen, id)
+	delete(d.states, id)
+}
+
+// Heartbeat records that the node answered just now.
+func (d *Detector) Heartbeat(id string) {
+	d.Lock()
+	d.lastSeen[id] = time.Now()
+	previous, exists := d.states[id]
+	d.states[id] = Alive
+	d.Unlock()
+	if exists && previous != Alive {
+		d.notify(id, Alive)
+	}
+}
+
+// Check updates the state of every node according to how long it has been silent.
+func (d *Detector) Check(now time.Time) {
+	type change struct {
+		id    string
+		state State
+	}
+	var changes []change
+	d.Lock()
+	for id, lastSeen := range d.lastSeen {
+		state := Alive
+		silence := now.Sub(lastSeen)
+		if silence >= d.deadAfter {
+			state = Dead
+		} else if silence >= d.suspectAfter {
+			state = Suspect
+		}
+		if d.states[id] != state {
+			d.states[id] = state
+			changes = append(changes, change{id: id, state: state})
+		}
+	}
+	d.Unlock()
+	for _, c := range changes {
+		d.notify(c.id, c.state)
+	}
+}
+
+func (d *Detector) State(id string) State {
+	d.RLock()
+	defer d.RUnlock()
+	return d.states[id]
+}
+
+// States returns a copy of the state of every monitored node.
+func (d *Detector) States() map[string]State {
+	d.RLock()
+	defer d.RUnlock()
+	states := make(map[string]State, len(d.states))
+	for id, state := range d.states {
+		states[id] = state
+	}
+	return states
+}
+
+func (d *Detector) notify(id string, state State) {
+	if d.OnChange != nil {
+		d.OnChange(id, state)
+	}
+}
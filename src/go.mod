@@ -3,5 +3,17 @@ module CloudShoppingList
 go 1.21.3
 
 require (
+	github.com/cespare/xxhash/v2 v2.2.0
 	github.com/mattn/go-sqlite3 v1.14.18
+	github.com/spaolacci/murmur3 v1.1.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20231002182017-d307bd883b97
+	google.golang.org/grpc v1.60.0
+	google.golang.org/protobuf v1.31.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.16.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
 )
@@ -2,295 +2,1200 @@ package main
 
 import (
 	"CloudShoppingList/consistent_hashing"
-	"bytes"
+	"CloudShoppingList/crdt"
+	"CloudShoppingList/failure_detector"
+	"CloudShoppingList/membership"
+	"CloudShoppingList/message"
+	"CloudShoppingList/rpc"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
 	"log"
-	"mime/multipart"
+	"math/rand"
 	"net/http"
-	"strconv"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+)
+
+// Gossip with the storage servers, the load balancer learns the ring and watches the
+// heartbeats of the servers from it
+const (
+	gossipInterval = time.Second
+	gossipTimeout  = time.Second
+	// servers tried in a round before giving up until the next one
+	gossipAttempts = 3
+	suspectAfter   = 3 * time.Second
+	deadAfter      = 10 * time.Second
 )
 
 type LoadBalancer struct {
-	Ring    *consistent.Ring
-	Servers []string
+	// partitioner chooses the servers of every key, the one the membership names
+	partitioner     consistent.Partitioner
+	partitionerLock sync.RWMutex
+	// Config is the shape of the ring the servers joining through this load balancer get
+	Config consistent.RingConfig
+	// Members are the servers of the ring as gossiped by the servers
+	Members  *membership.List
+	Detector *detector.Detector
+	// Seeds are servers asked for the ring before any other is known
+	Seeds []string
+	// Transport carries the requests to the servers
+	Transport rpc.Transport
+	// WriteQuorum is the number of replicas (W out of N = 1 + ReplicationFactor)
+	// that must acknowledge a write before the client gets a success response
+	WriteQuorum int
+	// ReadQuorum is the number of replicas (R out of N) whose states are merged on a read
+	ReadQuorum int
+	// servers still receiving their keys after joining, they take writes but no reads
+	joining     map[string]bool
+	joiningLock sync.Mutex
+	// StatePath is the file the membership is cached in on every change, none when empty
+	StatePath string
+	stateLock sync.Mutex
+	// requests sent to every server by address, for /admin/requests
+	requests     map[string]*message.NodeRequests
+	requestsLock sync.Mutex
+	// servers whose heartbeat was gossiped since the start, a cached heartbeat is too old
+	// to tell whether the server still beats
+	heard     map[string]bool
+	heardLock sync.Mutex
+}
+
+func NewLoadBalancer(partitioner consistent.Partitioner, config consistent.RingConfig, writeQuorum, readQuorum int, transport rpc.Transport) *LoadBalancer {
+	lb := &LoadBalancer{
+		partitioner: partitioner,
+		Config:      config,
+		Members:     membership.NewList(),
+		Detector:    detector.NewDetector(suspectAfter, deadAfter),
+		Transport:   transport,
+		WriteQuorum: writeQuorum,
+		ReadQuorum:  readQuorum,
+		joining:     make(map[string]bool),
+		heard:       make(map[string]bool),
+		requests:    make(map[string]*message.NodeRequests),
+	}
+	// dead nodes stay in the ring but stop receiving requests until they beat again
+	lb.Detector.OnChange = func(id string, state detector.State) {
+		fmt.Printf("Node %s is now %s\n", id, state)
+		lb.Partitioner().SetAvailable(id, state != detector.Dead)
+	}
+	return lb
+}
+
+// Partitioner chooses the servers of every key, as the servers place themselves with it.
+func (lb *LoadBalancer) Partitioner() consistent.Partitioner {
+	lb.partitionerLock.RLock()
+	defer lb.partitionerLock.RUnlock()
+	return lb.partitioner
+}
+
+// usePartitioner switches to the partitioner called name, with the replication factor and
+// hash function of the current one, keeping dead servers unavailable.
+func (lb *LoadBalancer) usePartitioner(name string) {
+	current := lb.Partitioner()
+	config := consistent.RingConfig{VirtualNodes: lb.Config.VirtualNodes, ReplicationFactor: current.ReplicationFactor(), Hasher: current.Hasher()}
+	partitioner, err := consistent.NewPartitioner(name, config)
+	if err != nil {
+		fmt.Println("Keeping my partitioner:", err)
+		return
+	}
+	fmt.Printf("The ring places keys with %s, not %s as configured\n", name, current.Name())
+	for id, state := range lb.Detector.States() {
+		partitioner.SetAvailable(id, state != detector.Dead)
+	}
+	lb.partitionerLock.Lock()
+	lb.partitioner = partitioner
+	lb.partitionerLock.Unlock()
+}
+
+// mergeMembership takes in the records gossiped by a server. Servers whose heartbeat went up
+// are alive, and the ring is placed again when its members changed. The membership of a ring
+// hashing keys with another function is refused.
+func (lb *LoadBalancer) mergeMembership(membership *message.Membership) error {
+	// the larger replication factor wins, it has to leave enough replicas for the quorums
+	if replicationFactor := max(membership.ReplicationFactor, lb.Members.ReplicationFactor()); replicationFactor > 0 {
+		if replicas := 1 + replicationFactor; lb.WriteQuorum > replicas || lb.ReadQuorum > replicas {
+			return fmt.Errorf("the ring keeps %d replicas of every key, fewer than the quorums W=%d and R=%d", replicas, lb.WriteQuorum, lb.ReadQuorum)
+		}
+	}
+	changed, beating, err := lb.Members.Merge(membership)
+	if err != nil {
+		return err
+	}
+	if changed {
+		lb.updateRing()
+	}
+	lb.heardLock.Lock()
+	var alive []string
+	for _, id := range beating {
+		if lb.heard[id] {
+			alive = append(alive, id)
+		}
+	}
+	for _, member := range membership.Members {
+		lb.heard[member.ID] = true
+	}
+	lb.heardLock.Unlock()
+	for _, id := range alive {
+		lb.Detector.Heartbeat(id)
+	}
+	return nil
+}
+
+// updateRing places the members on the ring. Servers new to the load balancer get no
+// requests until their heartbeat goes up.
+func (lb *LoadBalancer) updateRing() {
+	lb.stateLock.Lock()
+	defer lb.stateLock.Unlock()
+	if name := lb.Members.Partitioner(); name != "" && name != lb.Partitioner().Name() {
+		lb.usePartitioner(name)
+	}
+	ring := lb.Partitioner()
+	members, epoch := lb.Members.Ring()
+	if epoch < ring.Epoch() {
+		// a newer ring is already in place
+		return
+	}
+	states := lb.Detector.States()
+	inRing := make(map[string]bool)
+	joining := make(map[string]bool)
+	for _, member := range members {
+		inRing[member.ID] = true
+		if _, watched := states[member.ID]; !watched {
+			lb.Detector.AddUnverified(member.ID)
+			ring.SetAvailable(member.ID, false)
+		}
+		if record, _ := lb.Members.Get(member.ID); record.Status == message.Joining {
+			joining[member.Server] = true
+		}
+	}
+	for id := range states {
+		if !inRing[id] {
+			lb.Detector.Remove(id)
+		}
+	}
+	// the ring keeps the replication factor it was created with, whatever -rf says
+	if replicationFactor := lb.Members.ReplicationFactor(); replicationFactor > 0 && replicationFactor != ring.ReplicationFactor() {
+		fmt.Printf("The ring replicates keys %d times, not %d as configured\n", replicationFactor, ring.ReplicationFactor())
+		ring.SetReplicationFactor(replicationFactor)
+	}
+	if hash := lb.Members.Hash(); hash != "" && hash != ring.Hasher().Name() {
+		hasher, err := consistent.NewHasher(hash)
+		if err != nil {
+			fmt.Println("Keeping my hash function:", err)
+		} else {
+			fmt.Printf("The ring hashes keys with %s, not %s as configured\n", hash, ring.Hasher().Name())
+			ring.SetHasher(hasher)
+		}
+	}
+	ring.Restore(members, epoch)
+	lb.joiningLock.Lock()
+	lb.joining = joining
+	lb.joiningLock.Unlock()
+	fmt.Printf("Moved to the ring of epoch %d with %d servers\n", epoch, len(members))
+
+	if lb.StatePath != "" {
+		err := lb.Members.Save(lb.StatePath)
+		if err != nil {
+			fmt.Println("Error saving membership:", err)
+		}
+	}
+}
+
+// loadMembership reads the membership cached at StatePath. The servers get no requests until
+// they beat again.
+func (lb *LoadBalancer) loadMembership() error {
+	err := lb.Members.Load(lb.StatePath)
+	if err != nil {
+		return err
+	}
+	lb.updateRing()
+	return nil
+}
+
+// gossip exchanges the membership with a random server, trying another one when it cannot be reached.
+func (lb *LoadBalancer) gossip() {
+	for _, server := range lb.gossipTargets() {
+		err := lb.gossipWith(server)
+		if err == nil {
+			return
+		}
+		fmt.Println("Error gossiping with server "+server+":", err)
+	}
+}
+
+// gossipTargets returns up to gossipAttempts servers in random order, the ones that are not
+// dead first. The seeds are used until a server is known.
+func (lb *LoadBalancer) gossipTargets() []string {
+	var alive, dead []string
+	for _, member := range lb.Members.Membership().Members {
+		if member.Status == message.Left {
+			continue
+		}
+		if lb.Detector.State(member.ID) == detector.Dead {
+			dead = append(dead, member.Address)
+		} else {
+			alive = append(alive, member.Address)
+		}
+	}
+	if len(alive)+len(dead) == 0 {
+		alive = append(alive, lb.Seeds...)
+	}
+	rand.Shuffle(len(alive), func(i, j int) { alive[i], alive[j] = alive[j], alive[i] })
+	rand.Shuffle(len(dead), func(i, j int) { dead[i], dead[j] = dead[j], dead[i] })
+	targets := append(alive, dead...)
+	if len(targets) > gossipAttempts {
+		targets = targets[:gossipAttempts]
+	}
+	return targets
 }
 
-func NewLoadBalancer() *LoadBalancer {
-	return &LoadBalancer{
-		Ring:    consistent.NewRing(),
-		Servers: []string{},
+func (lb *LoadBalancer) gossipWith(server string) error {
+	membership, status, err := lb.Transport.Gossip(server, lb.Members.Membership(), gossipTimeout)
+	if err != nil {
+		return err
 	}
+	if status != http.StatusOK {
+		return message.UnexpectedStatus(server, status)
+	}
+	return lb.mergeMembership(membership)
 }
 
-func (lb *LoadBalancer) AddNode(id, server string) {
-	lb.Ring.AddNode(id, server)
-	lb.Servers = append(lb.Servers, server)
+// memberOf returns the record of the server called id, unless it left the ring.
+func (lb *LoadBalancer) memberOf(id string) (message.Member, bool) {
+	member, exists := lb.Members.Get(id)
+	if !exists || member.Status == message.Left {
+		return message.Member{}, false
+	}
+	return member, true
 }
 
 func (lb *LoadBalancer) Put(email string) ([]string, error) {
-	return lb.Ring.Put(email)
+	return lb.Partitioner().Put(email)
 }
 
 func (lb *LoadBalancer) Get(email string) (string, error) {
-	return lb.Ring.Get(email)
+	return lb.Partitioner().Get(email)
 }
 
 func (lb *LoadBalancer) GetNodeAndReplicas(email string) ([]string, error) {
-	return lb.Ring.GetNodeAndReplicas(email)
+	return lb.Partitioner().GetNodeAndReplicas(email)
 }
 
 func (lb *LoadBalancer) HandleNodeConnection(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("Received node connection")
-
 	// Read the node ID and server address from the request body
-	body, err := io.ReadAll(r.Body)
+	var connect message.ConnectNode
+	err := message.ReadRequest(r, &connect)
 	if err != nil {
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		message.Error(w, "Error parsing request body", http.StatusBadRequest)
 		return
 	}
-
-	// Split the body into the node ID and server address using comma as the separator
-	parts := strings.Split(string(body), ",")
-	if len(parts) != 2 {
-		http.Error(w, "Invalid request body format", http.StatusBadRequest)
+	membership, err := lb.ConnectNode(&connect)
+	if err != nil {
+		message.Fail(w, err)
 		return
 	}
+	err = message.Write(w, http.StatusOK, membership)
+	if err != nil {
+		fmt.Println("Error writing response:", err)
+	}
+}
+
+// ConnectNode adds a new server to the membership as joining and spreads it to the ring. The
+// server gets the membership back and receives its keys by itself, then gossips that it is ready.
+func (lb *LoadBalancer) ConnectNode(connect *message.ConnectNode) (*message.Membership, error) {
+	fmt.Println("Received node connection")
+	nodeID := connect.ID
+	nodeAddress := connect.Address
+
+	// the lists of a ring are stored under the hash of their email, so the ring keeps its hash
+	// function: the one known here, else the one of the ring the server was in, else -hash
+	hash := lb.Members.Hash()
+	if hash == "" {
+		hash = connect.Hash
+	}
+	if connect.Hash != "" && connect.Hash != hash {
+		return nil, message.Errorf(http.StatusConflict, "The server was in a ring hashing keys with %s, this one uses %s", connect.Hash, hash)
+	}
+	if hash == "" {
+		hash = lb.Partitioner().Hasher().Name()
+	}
+	if _, err := consistent.NewHasher(hash); err != nil {
+		return nil, message.Errorf(http.StatusBadRequest, "%v", err)
+	}
+	// the servers hold the ranges of the partitioner, so the ring keeps it the same way
+	partitioner := lb.Members.Partitioner()
+	if partitioner == "" {
+		partitioner = connect.Partitioner
+	}
+	if connect.Partitioner != "" && connect.Partitioner != partitioner {
+		return nil, message.Errorf(http.StatusConflict, "The server was in a ring placing keys with %s, this one uses %s", connect.Partitioner, partitioner)
+	}
+	if partitioner == "" {
+		partitioner = lb.Partitioner().Name()
+	}
+	if _, err := consistent.NewPartitioner(partitioner, lb.Config); err != nil {
+		return nil, message.Errorf(http.StatusBadRequest, "%v", err)
+	}
 
-	nodeID := parts[0]
-	nodeAddress := parts[1]
+	// A restarted node is still in the ring, it only needs the membership again
+	if member, exists := lb.memberOf(nodeID); exists {
+		fmt.Printf("Node %s at address %s reconnected\n", nodeID, nodeAddress)
+		if connect.Weight > 0 && connect.Weight != max(member.Weight, 1) {
+			fmt.Printf("Node %s keeps weight %d, change it with /set-weight\n", nodeID, max(member.Weight, 1))
+		}
+		if connect.Zone != member.Zone {
+			fmt.Printf("Node %s stays in zone %q, it joined the ring there\n", nodeID, member.Zone)
+		}
+		lb.Detector.Heartbeat(nodeID)
+		return lb.Members.Membership(), nil
+	}
 
-	// Add the node to the ring
-	lb.AddNode(nodeID, nodeAddress)
+	// a server coming back after it left needs a newer record than the one it left with
+	weight := max(connect.Weight, 1)
+	member := message.Member{ID: nodeID, Address: nodeAddress, VirtualNodes: lb.Config.VirtualNodesFor(weight), Weight: weight, Zone: connect.Zone, Status: message.Joining, Version: 1}
+	if left, exists := lb.Members.Get(nodeID); exists {
+		member.Version = left.Version + 1
+	}
+	// it just reached the load balancer, so it is alive
+	lb.Detector.Add(nodeID)
+	// the first server to join sets the replication factor, hash function and partitioner of the ring for good
+	err := lb.mergeMembership(&message.Membership{ReplicationFactor: lb.Partitioner().ReplicationFactor(), Hash: hash, Partitioner: partitioner, Members: []message.Member{member}})
+	if err != nil {
+		return nil, message.Errorf(http.StatusConflict, "%v", err)
+	}
 	fmt.Printf("Added node %s at address %s\n", nodeID, nodeAddress)
-	lb.Ring.PrintNodes()
-	lb.Ring.PrintNeighbors()
-	w.WriteHeader(http.StatusOK)
-	go lb.shareNeighboursAndReceiveKeys(nodeAddress)
+
+	// the rest of the ring learns about the server from any other server
+	for _, server := range lb.gossipTargets() {
+		if server == nodeAddress {
+			continue
+		}
+		err := lb.gossipWith(server)
+		if err == nil {
+			break
+		}
+		fmt.Println("Error gossiping with server "+server+":", err)
+	}
+	return lb.Members.Membership(), nil
 }
 
-func (lb *LoadBalancer) HandleShoppingListPut(w http.ResponseWriter, r *http.Request) {
-	// Read the request body
-	err := r.ParseMultipartForm(32 << 20)
+func (lb *LoadBalancer) HandleNodeDisconnection(w http.ResponseWriter, r *http.Request) {
+	// Read the node ID from the request body
+	var disconnect message.DisconnectNode
+	err := message.ReadRequest(r, &disconnect)
 	if err != nil {
-		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		message.Error(w, "Error parsing request body", http.StatusBadRequest)
 		return
 	}
-	email := r.FormValue("email")
-	fmt.Println("Email:", email)
-
-	file, handler, err := r.FormFile("file")
+	err = lb.DisconnectNode(&disconnect)
 	if err != nil {
-		http.Error(w, "Error reading file", http.StatusBadRequest)
+		message.Fail(w, err)
 		return
 	}
-	defer func(file multipart.File) {
-		err := file.Close()
-		if err != nil {
+	w.WriteHeader(http.StatusOK)
+}
 
-		}
-	}(file)
-	fmt.Printf("Received file %s\n", handler.Filename)
-	// Get the node ID for the email
-	servers, err := lb.Put(email)
+// DisconnectNode asks the server to leave the ring. The server streams every affected range to
+// its new owners, then gossips that it left.
+func (lb *LoadBalancer) DisconnectNode(disconnect *message.DisconnectNode) error {
+	fmt.Println("Received node disconnection")
+	nodeID := disconnect.ID
+	member, exists := lb.memberOf(nodeID)
+	if !exists {
+		return message.Errorf(http.StatusNotFound, "Unknown node")
+	}
+
+	status, err := lb.Transport.Leave(member.Address, disconnect)
 	if err != nil {
-		// If there is an error getting the node ID, respond with an internal server error
-		http.Error(w, "Error getting node ID", http.StatusInternalServerError)
+		fmt.Println("Error asking the server to leave:", err)
+		return message.Errorf(http.StatusBadGateway, "Error reaching the leaving node")
+	}
+	if status != http.StatusOK {
+		return message.Errorf(status, "The node could not leave the ring")
+	}
+
+	// the server knows it left, the other servers learn it from the gossip
+	err = lb.gossipWith(member.Address)
+	if err != nil {
+		fmt.Println("Error gossiping with server "+member.Address+":", err)
+	}
+	fmt.Printf("Removed node %s at address %s\n", nodeID, member.Address)
+	return nil
+}
+
+func (lb *LoadBalancer) HandleSetWeight(w http.ResponseWriter, r *http.Request) {
+	var weight message.NodeWeight
+	err := message.ReadRequest(r, &weight)
+	if err != nil {
+		message.Error(w, "Error parsing request body", http.StatusBadRequest)
 		return
 	}
-	contents, err := io.ReadAll(file)
+	err = lb.SetWeight(&weight)
 	if err != nil {
-		fmt.Println("Error reading file:", err)
+		message.Fail(w, err)
 		return
 	}
+	w.WriteHeader(http.StatusOK)
+}
 
-	// Send the file to all servers simultaneously
-	for _, server := range servers {
-		fmt.Printf("Sending file to server %s\n", server)
+// SetWeight asks the server to take the virtual nodes of a new weight. The server hands over
+// or receives the ranges that change hands, then gossips its new record.
+func (lb *LoadBalancer) SetWeight(weight *message.NodeWeight) error {
+	fmt.Println("Received weight change")
+	if weight.Weight < 1 {
+		return message.Errorf(http.StatusBadRequest, "The weight must be at least 1")
+	}
+	member, exists := lb.memberOf(weight.ID)
+	if !exists {
+		return message.Errorf(http.StatusNotFound, "Unknown node")
+	}
 
-		// Create a new multipart form
-		body := &bytes.Buffer{}
-		writer := multipart.NewWriter(body)
+	request := &message.NodeWeight{ID: weight.ID, Weight: weight.Weight, VirtualNodes: lb.Config.VirtualNodesFor(weight.Weight)}
+	status, err := lb.Transport.SetWeight(member.Address, request)
+	if err != nil {
+		fmt.Println("Error asking the server to change its weight:", err)
+		return message.Errorf(http.StatusBadGateway, "Error reaching the node")
+	}
+	if status != http.StatusOK {
+		return message.Errorf(status, "The node could not change its weight")
+	}
 
-		// Write the email to the form
-		err = writer.WriteField("email", email)
-		if err != nil {
-			fmt.Println("Error writing to form field:", err)
-			return
-		}
+	err = lb.gossipWith(member.Address)
+	if err != nil {
+		fmt.Println("Error gossiping with server "+member.Address+":", err)
+	}
+	fmt.Printf("Node %s now has weight %d with %d virtual nodes\n", weight.ID, weight.Weight, request.VirtualNodes)
+	return nil
+}
 
-		// Create a new form file
-		part, err := writer.CreateFormFile("file", handler.Filename)
-		if err != nil {
-			fmt.Println("Error creating form file:", err)
-			return
+func (lb *LoadBalancer) HandleRebalancePlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		message.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var plan message.RebalancePlan
+	err := message.ReadRequest(r, &plan)
+	if err != nil {
+		message.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	moves, err := lb.PlanRebalance(&plan)
+	if err != nil {
+		message.Fail(w, err)
+		return
+	}
+	err = message.Write(w, http.StatusOK, moves)
+	if err != nil {
+		fmt.Println("Error writing response:", err)
+	}
+}
+
+// PlanRebalance returns the key ranges the servers would copy to each other if the change of
+// plan was made, without making it.
+func (lb *LoadBalancer) PlanRebalance(plan *message.RebalancePlan) (*message.RebalanceMoves, error) {
+	change := consistent.Change{Remove: plan.Remove}
+	for _, node := range plan.Add {
+		if node.ID == "" || node.Address == "" {
+			return nil, message.Errorf(http.StatusBadRequest, "Every added node needs an id and an address")
 		}
+		if node.Weight < 0 {
+			return nil, message.Errorf(http.StatusBadRequest, "The weight must be at least 1")
+		}
+		weight := max(node.Weight, 1)
+		change.Add = append(change.Add, consistent.Member{ID: node.ID, Server: node.Address, VirtualNodes: lb.Config.VirtualNodesFor(weight), Zone: node.Zone})
+	}
+	epoch := lb.Partitioner().Epoch()
+	transfers, err := lb.Partitioner().Plan(change)
+	if err != nil {
+		return nil, message.Errorf(http.StatusBadRequest, "%v", err)
+	}
 
-		// Write the file contents to the form file
-		_, err = part.Write(contents)
-		if err != nil {
-			fmt.Println("Error writing to form file:", err)
-			return
+	moves := &message.RebalanceMoves{Epoch: epoch, Moves: []message.Move{}}
+	for _, transfer := range transfers {
+		keyRange := message.KeyRange{Start: transfer.Range.Start, End: transfer.Range.End}
+		moves.Moves = append(moves.Moves, message.Move{Range: keyRange, From: transfer.From, To: transfer.To})
+	}
+	return moves, nil
+}
+
+// printRebalance prints the moves of plan, for the -dry-run flag.
+func (lb *LoadBalancer) printRebalance(plan *message.RebalancePlan) error {
+	moves, err := lb.PlanRebalance(plan)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d ranges would move at epoch %d\n", len(moves.Moves), moves.Epoch)
+	for _, move := range moves.Moves {
+		fmt.Printf("(%x, %x] from %s to %s\n", move.Range.Start, move.Range.End, move.From, move.To)
+	}
+	return nil
+}
+
+// parsePlan reads the -add and -remove flags of a dry run. Servers are added as id=address,
+// or id=address@weight to give them a weight, and removed by id, both comma separated.
+func parsePlan(add, remove string) (*message.RebalancePlan, error) {
+	plan := &message.RebalancePlan{}
+	for _, entry := range strings.Split(add, ",") {
+		if entry == "" {
+			continue
+		}
+		id, address, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("server to add %q is not id=address", entry)
 		}
+		node := message.PlannedNode{ID: id, Address: address}
+		if address, weight, found := strings.Cut(address, "@"); found {
+			node.Address = address
+			_, err := fmt.Sscan(weight, &node.Weight)
+			if err != nil || node.Weight < 1 {
+				return nil, fmt.Errorf("weight of server to add %q is not a positive number", entry)
+			}
+		}
+		plan.Add = append(plan.Add, node)
+	}
+	for _, id := range strings.Split(remove, ",") {
+		if id != "" {
+			plan.Remove = append(plan.Remove, id)
+		}
+	}
+	if len(plan.Add)+len(plan.Remove) == 0 {
+		return nil, errors.New("a dry run needs servers to -add or -remove")
+	}
+	return plan, nil
+}
 
-		// Close the writer
-		err = writer.Close()
-		if err != nil {
-			fmt.Println("Error closing writer:", err)
-			return
+func (lb *LoadBalancer) HandleRingNodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		message.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	err := message.Write(w, http.StatusOK, lb.RingNodes())
+	if err != nil {
+		fmt.Println("Error writing response:", err)
+	}
+}
+
+// RingNodes lists the servers of the ring with their health and the ranges they own, each
+// ending at a position with its front neighbours, the replicas of the range, and on a ring its
+// back neighbours, the positions it replicates.
+func (lb *LoadBalancer) RingNodes() *message.RingNodes {
+	partitions, epoch := lb.Partitioner().Partitions()
+	back := make(map[string][]consistent.Node)
+	index := make(map[string]int)
+	for i, partition := range partitions {
+		index[partition.Nodes[0].Id] = i
+		for _, front := range partition.Nodes[1:] {
+			back[front.Id] = append(back[front.Id], partition.Nodes[0])
 		}
+	}
+	positions := make(map[string][]message.RingPosition)
+	for i, partition := range partitions {
+		owner := partition.Nodes[0]
+		position := message.RingPosition{ID: owner.Id, Hash: partition.Range.End, Virtual: owner.IsVirtual, Front: ringNeighbors(partition.Nodes[1:]), Back: []message.RingNeighbor{}}
+		// the members of the other partitioners own many ranges, they have no positions to precede
+		if len(owner.HashId) > 0 {
+			// the nearest back neighbour first
+			backNodes := back[owner.Id]
+			distance := func(node consistent.Node) int {
+				return (i - index[node.Id] + len(partitions)) % len(partitions)
+			}
+			sort.Slice(backNodes, func(a, b int) bool { return distance(backNodes[a]) < distance(backNodes[b]) })
+			position.Back = ringNeighbors(backNodes)
+		}
+		positions[serverOf(owner)] = append(positions[serverOf(owner)], position)
+	}
 
-		// Create a new request
-		req, err := http.NewRequest("POST", "http://"+server+"/putListServer", body)
-		if err != nil {
-			fmt.Println("Error creating request:", err)
-			return
+	states := lb.Detector.States()
+	list := &message.RingNodes{Epoch: epoch, Nodes: []message.RingNode{}}
+	for _, member := range lb.Members.Membership().Members {
+		if member.Status == message.Left {
+			continue
 		}
+		list.Nodes = append(list.Nodes, message.RingNode{
+			ID:        member.ID,
+			Address:   member.Address,
+			Weight:    max(member.Weight, 1),
+			Zone:      member.Zone,
+			Status:    member.Status,
+			Health:    health(states, member.ID),
+			Positions: positions[member.ID],
+		})
+	}
+	return list
+}
 
-		// Set the content type header
-		req.Header.Set("Content-Type", writer.FormDataContentType())
+// serverOf is the id of the server a position of the ring belongs to.
+func serverOf(node consistent.Node) string {
+	if node.IsVirtual {
+		return node.RealNodeId
+	}
+	return node.Id
+}
 
-		// Send the request
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			fmt.Println("Error sending request:", err)
-			continue
+func ringNeighbors(nodes []consistent.Node) []message.RingNeighbor {
+	neighbors := []message.RingNeighbor{}
+	for _, node := range nodes {
+		neighbors = append(neighbors, message.RingNeighbor{ID: node.Id, Hash: node.HashId, Server: serverOf(node)})
+	}
+	return neighbors
+}
+
+// health is the state of the server id in states, unknown when it is not monitored.
+func health(states map[string]detector.State, id string) string {
+	state, exists := states[id]
+	if !exists {
+		return "unknown"
+	}
+	return state.String()
+}
+
+// memberAt returns the record of the server listening on address, unless it left the ring.
+func (lb *LoadBalancer) memberAt(address string) (message.Member, bool) {
+	for _, member := range lb.Members.Membership().Members {
+		if member.Address == address && member.Status != message.Left {
+			return member, true
 		}
+	}
+	return message.Member{}, false
+}
 
-		// Close the response body
-		err = resp.Body.Close()
-		if err != nil {
-			fmt.Println("Error closing response body:", err)
-			continue
+func (lb *LoadBalancer) HandleKeyOwners(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		message.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	email := strings.TrimPrefix(r.URL.Path, "/admin/owners/")
+	owners, err := lb.KeyOwners(email)
+	if err != nil {
+		message.Fail(w, err)
+		return
+	}
+	err = message.Write(w, http.StatusOK, owners)
+	if err != nil {
+		fmt.Println("Error writing response:", err)
+	}
+}
+
+// KeyOwners returns the natural owner and replicas of the list of email with their health,
+// whether they are up or stood in for.
+func (lb *LoadBalancer) KeyOwners(email string) (*message.KeyOwners, error) {
+	if email == "" {
+		return nil, message.Errorf(http.StatusBadRequest, "No email given")
+	}
+	epoch := lb.Partitioner().Epoch()
+	servers, err := lb.Partitioner().PreferenceList(email)
+	if err != nil {
+		return nil, message.Errorf(http.StatusServiceUnavailable, "The ring is empty")
+	}
+
+	states := lb.Detector.States()
+	owners := &message.KeyOwners{Email: email, Hash: lb.Partitioner().Hasher().Hash([]byte(email)), Epoch: epoch}
+	for _, server := range servers {
+		owner := message.KeyServer{Address: server, Health: "unknown"}
+		if member, exists := lb.memberAt(server); exists {
+			owner.ID = member.ID
+			owner.Health = health(states, member.ID)
 		}
+		owners.Servers = append(owners.Servers, owner)
+	}
+	return owners, nil
+}
 
-		// Check the response status code
-		if resp.StatusCode != http.StatusOK {
-			fmt.Println("Error sending file to server:", resp.Status)
+// countRequest counts a write, or a read when put is false, sent to server.
+func (lb *LoadBalancer) countRequest(server string, put bool, failed bool) {
+	lb.requestsLock.Lock()
+	defer lb.requestsLock.Unlock()
+	count, exists := lb.requests[server]
+	if !exists {
+		count = &message.NodeRequests{Address: server}
+		lb.requests[server] = count
+	}
+	if put {
+		count.Puts++
+	} else {
+		count.Gets++
+	}
+	if failed {
+		count.Failures++
+	}
+}
+
+func (lb *LoadBalancer) HandleRequestCounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		message.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	err := message.Write(w, http.StatusOK, lb.RequestCounts())
+	if err != nil {
+		fmt.Println("Error writing response:", err)
+	}
+}
+
+// RequestCounts returns the requests sent to every server of the ring, and to the servers
+// that left it after getting some.
+func (lb *LoadBalancer) RequestCounts() *message.RequestCounts {
+	lb.requestsLock.Lock()
+	counts := make(map[string]message.NodeRequests, len(lb.requests))
+	for server, count := range lb.requests {
+		counts[server] = *count
+	}
+	lb.requestsLock.Unlock()
+
+	list := &message.RequestCounts{Nodes: []message.NodeRequests{}}
+	for _, member := range lb.Members.Membership().Members {
+		count, exists := counts[member.Address]
+		if member.Status == message.Left && !exists {
 			continue
 		}
+		count.ID = member.ID
+		count.Address = member.Address
+		list.Nodes = append(list.Nodes, count)
+		delete(counts, member.Address)
+	}
+	var others []message.NodeRequests
+	for _, count := range counts {
+		others = append(others, count)
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i].Address < others[j].Address })
+	list.Nodes = append(list.Nodes, others...)
+	return list
+}
 
-		// Print a success message
-		fmt.Println("Sent file to server successfully")
+func (lb *LoadBalancer) HandleShoppingListPut(w http.ResponseWriter, r *http.Request) {
+	// Read the request body
+	var put message.PutList
+	err := message.ReadRequest(r, &put)
+	if err != nil {
+		message.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	err = lb.PutList(&put)
+	if err != nil {
+		message.Fail(w, err)
+		return
 	}
 	// Send a success response (HTTP 200 OK) to the client
 	w.WriteHeader(http.StatusOK)
 }
 
-func (lb *LoadBalancer) shareNeighboursInformation() {
-	for _, server := range lb.Servers {
-
-		// start building the message strings seperated by commas
-		// "nodeId:NodehashId,frontNeighbour1:frontNeighbour1HashId,frontNeighbour2:frontNeighbour2HashId
-		//,backNeighbour1:backNeighbour1HashId,backNeighbour2:backNeighbour2HashId" and so on
-		message := ""
-		// iterate over the nodes array
-		for _, node := range lb.Ring.Nodes {
-			if node.Server == server {
-				currentNode := node
-				frontNeighbours := node.FrontNodes
-				backNeighbours := node.BackNodes
-				message = message + currentNode.Id + ":::" + string(currentNode.HashId)
-				for i, frontNode := range frontNeighbours {
-					message = message + ",,," + "frontNeighbor" + strconv.Itoa(i+1) + ":::" + frontNode.Server + ":::" + string(frontNode.HashId)
-				}
-				for i, backNode := range backNeighbours {
-					message = message + ",,," + "backNeighbor" + strconv.Itoa(i+1) + ":::" + backNode.Server + ":::" + string(backNode.HashId)
-				}
-				message = message + "****"
+// Times a request is routed again after a server answered that the ring changed
+const routingAttempts = 3
+
+// errStaleRoute is returned when the quorum was missed because servers already knew
+// a newer ring than the one the request was routed with.
+var errStaleRoute = errors.New("routed with a stale ring")
+
+func (lb *LoadBalancer) PutList(put *message.PutList) error {
+	for attempt := 1; attempt <= routingAttempts; attempt++ {
+		err := lb.putList(put)
+		if err != errStaleRoute {
+			return err
+		}
+		fmt.Println("The ring changed while routing the write, routing it again")
+		lb.gossip()
+	}
+	return message.Errorf(http.StatusServiceUnavailable, "The ring is changing, try again")
+}
+
+func (lb *LoadBalancer) putList(request *message.PutList) error {
+	email := request.Email
+	fmt.Println("Email:", email)
+	// Route with the current ring, the servers refuse it once they know a newer one
+	put := *request
+	put.Epoch = lb.Partitioner().Epoch()
+	// Get the node ID for the email
+	servers, err := lb.Put(email)
+	if err != nil {
+		// If there is an error getting the node ID, respond with an internal server error
+		return message.Errorf(http.StatusInternalServerError, "Error getting node ID")
+	}
+
+	// Servers after the preference list take the writes of unreachable replicas
+	candidates, err := lb.Partitioner().HandoffCandidates(email)
+	if err != nil {
+		fmt.Println("Error getting handoff candidates:", err)
+	}
+	// Servers standing in for dead replicas keep the list with a hint as well
+	standIns := lb.Partitioner().StandIns(email)
+
+	// Send the file to all servers simultaneously
+	results := make(chan writeResult, len(servers)+len(candidates))
+	for _, server := range servers {
+		go func(server, hint string) {
+			fmt.Printf("Sending file to server %s\n", server)
+			status, err := lb.sendListToServer(server, put, hint)
+			results <- writeResult{server: server, hintFor: hint, status: status, err: err}
+		}(server, standIns[server])
+	}
+
+	// Wait until W replicas acknowledged the write, the rest finish in the background
+	acks := 0
+	needsFullState := false
+	staleRoute := false
+	for pending := len(servers); pending > 0; pending-- {
+		result := <-results
+		if result.err != nil {
+			fmt.Println("Error sending file to server "+result.server+":", result.err)
+			// sloppy quorum: the next healthy server keeps the list with a hint naming the intended owner
+			intended := result.server
+			if result.hintFor != "" {
+				intended = result.hintFor
+			}
+			if len(candidates) > 0 {
+				candidate := candidates[0]
+				candidates = candidates[1:]
+				pending++
+				go func(candidate, intended string) {
+					fmt.Printf("Handing off file for server %s to server %s\n", intended, candidate)
+					status, err := lb.sendListToServer(candidate, put, intended)
+					results <- writeResult{server: candidate, hintFor: intended, status: status, err: err}
+				}(candidate, intended)
 			}
+			continue
 		}
-		message = message[:len(message)-4]
-		fmt.Println("Message to be sent to server " + server + " is " + message)
-		// send the message to the server via plain text
-		resp, err := http.Post("http://"+server+"/shareNeighboursInformation", "text/plain", bytes.NewBufferString(message))
-		if err != nil {
-			fmt.Println("Error sending request to server", err)
-			return
+		// The replica knows a newer ring
+		if result.status == http.StatusConflict {
+			fmt.Println("Server " + result.server + " has a newer ring")
+			staleRoute = true
+			continue
 		}
-		defer resp.Body.Close()
-
-		// Check the response status code
-		if resp.StatusCode != http.StatusOK {
-			fmt.Println("Server responded with error", resp.Status)
-			return
+		// A replica without the list cannot apply a delta
+		if result.status == http.StatusPreconditionFailed {
+			fmt.Println("Server " + result.server + " needs the full shopping list")
+			needsFullState = true
+			continue
+		}
+		if result.status != http.StatusOK {
+			fmt.Printf("Error sending file to server %s: %d\n", result.server, result.status)
+			continue
+		}
+		fmt.Println("Sent file to server " + result.server + " successfully")
+		acks++
+		if acks >= lb.WriteQuorum {
+			break
 		}
+	}
 
-		// Print a success message
-		fmt.Println("Sent neighbours information to server" + server + " successfully")
+	if acks < lb.WriteQuorum {
+		if needsFullState {
+			return message.Errorf(http.StatusPreconditionFailed, "Full shopping list required")
+		}
+		if staleRoute {
+			return errStaleRoute
+		}
+		err = message.Errorf(http.StatusServiceUnavailable, "Write quorum not reached: %d of %d replicas acknowledged, %d required", acks, len(servers), lb.WriteQuorum)
+		fmt.Println(err)
+		return err
 	}
+	return nil
+}
+
+type writeResult struct {
+	server  string
+	hintFor string
+	status  int
+	err     error
+}
+
+// sendListToServer forwards a shopping list (or a delta of it) to the /putListServer endpoint of server.
+// A non-empty hint names the replica the list is meant for when server only keeps it on its behalf.
+func (lb *LoadBalancer) sendListToServer(server string, put message.PutList, hint string) (int, error) {
+	put.Hint = hint
+	status, err := lb.Transport.PutList(server, &put)
+	lb.countRequest(server, true, err != nil || status != http.StatusOK)
+	return status, err
 }
 
-func (lb *LoadBalancer) shareNeighboursAndReceiveKeys(server string) {
-	lb.shareNeighboursInformation()
-	//send request to server to get the keys
-	resp, err := http.Get("http://" + server + "/requestKeys")
+func (lb *LoadBalancer) HandleShoppingListGet(w http.ResponseWriter, r *http.Request) {
+	email := strings.TrimPrefix(r.URL.Path, "/list/")
+	list, err := lb.GetList(email)
 	if err != nil {
-		fmt.Println("Error sending request to server", err)
+		message.Fail(w, err)
+		return
 	}
-	defer resp.Body.Close()
 
-	// Check the response status code
-	if resp.StatusCode != http.StatusOK {
-		fmt.Println("Server responded with error", resp.Status)
-		return
+	// Send the merged shopping list to the client
+	err = message.Write(w, http.StatusOK, list)
+	if err != nil {
+		fmt.Println("Error writing response:", err)
 	}
+}
 
-	// Print a success message
-	fmt.Println("Signaled new server to receive its keys successfully")
+func (lb *LoadBalancer) GetList(email string) (*message.ShoppingList, error) {
+	for attempt := 1; attempt <= routingAttempts; attempt++ {
+		list, err := lb.getList(email)
+		if err != errStaleRoute {
+			return list, err
+		}
+		fmt.Println("The ring changed while routing the read, routing it again")
+		lb.gossip()
+	}
+	return nil, message.Errorf(http.StatusServiceUnavailable, "The ring is changing, try again")
 }
 
-func (lb *LoadBalancer) HandleShoppingListGet(w http.ResponseWriter, r *http.Request) {
-	email := strings.TrimPrefix(r.URL.Path, "/list/")
+func (lb *LoadBalancer) getList(email string) (*message.ShoppingList, error) {
 	fmt.Println("Email:", email)
+	epoch := lb.Partitioner().Epoch()
 
 	// Get the node ID for the email
 	servers, err := lb.GetNodeAndReplicas(email)
 	fmt.Println("Server:", servers)
 	if err != nil {
 		// If there is an error getting the node ID, respond with an internal server error
-		http.Error(w, "Error getting node ID", http.StatusInternalServerError)
-		return
+		return nil, message.Errorf(http.StatusInternalServerError, "Error getting node ID")
 	}
+	// Servers still receiving their keys do not have the list yet
+	servers = lb.withoutJoining(servers)
 
+	// Ask every replica at once and merge the first R answers
+	results := make(chan readResult, len(servers))
 	for _, server := range servers {
+		go func(server string) {
+			list, err := lb.fetchListFromServer(server, email, epoch)
+			results <- readResult{server: server, list: list, err: err}
+		}(server)
+	}
 
-		// Send the request to the server
-		resp, err := http.Get("http://" + server + "/getListServer/" + email)
-		if err != nil {
+	var merged *crdt.List
+	var answered []readResult
+	received := 0
+	staleRoute := false
+	for range servers {
+		result := <-results
+		received++
+		if result.err == errStaleRoute {
+			fmt.Println("Server " + result.server + " has a newer ring")
+			staleRoute = true
+			continue
+		}
+		if result.err != nil {
+			fmt.Println("Error getting shopping list from server "+result.server+":", result.err)
 			continue
 		}
-		defer resp.Body.Close()
+		// a replica without the list answered too, with an empty state
+		answered = append(answered, result)
+		if result.list != nil {
+			if merged == nil {
+				merged = crdt.NewList(result.list.ReplicaID)
+			}
+			merged.Join(result.list)
+		}
+		if len(answered) >= lb.ReadQuorum {
+			break
+		}
+	}
+	answers := len(answered)
+
+	if answers < lb.ReadQuorum {
+		if staleRoute {
+			return nil, errStaleRoute
+		}
+		err = message.Errorf(http.StatusServiceUnavailable, "Read quorum not reached: %d of %d replicas answered, %d required", answers, len(servers), lb.ReadQuorum)
+		fmt.Println(err)
+		return nil, err
+	}
+
+	if merged == nil {
+		go lb.readRepair(email, nil, answered, results, len(servers)-received)
+		return nil, message.Errorf(http.StatusNotFound, "Shopping list not found")
+	}
+	// the repair joins the late answers into its own copy, the answer is encoded already
+	list := &message.ShoppingList{Email: email, List: merged.ToGOB64()}
+	go lb.readRepair(email, crdt.FromGOB64(list.List), answered, results, len(servers)-received)
+	return list, nil
+}
 
-		// Check the response status code
-		if resp.StatusCode != http.StatusOK {
+// readRepair waits for the replicas that answered after the read quorum, then sends
+// every replica that is behind the merged list the mutations it is missing, and every
+// replica without the list the merged list.
+func (lb *LoadBalancer) readRepair(email string, merged *crdt.List, answered []readResult, results chan readResult, pending int) {
+	for i := 0; i < pending; i++ {
+		result := <-results
+		if result.err != nil {
 			continue
 		}
+		answered = append(answered, result)
+		if result.list != nil {
+			if merged == nil {
+				merged = crdt.NewList(result.list.ReplicaID)
+			}
+			merged.Join(result.list)
+		}
+	}
+	if merged == nil {
+		// no replica has the list
+		return
+	}
 
-		// Copy the response body to the client
-		_, err = io.Copy(w, resp.Body)
+	for _, replica := range answered {
+		// a replica without the list cannot apply a delta, it gets the whole list
+		put := message.PutList{Email: email, List: merged.ToGOB64()}
+		if replica.list != nil {
+			// the replica context tells exactly what it has not seen yet
+			delta := merged.Delta(replica.list.Cc)
+			if delta.IsEmpty() {
+				continue
+			}
+			put = message.PutList{Email: email, List: delta.ToGOB64(), Delta: true}
+		}
+		fmt.Println("Repairing shopping list " + email + " on server " + replica.server)
+		status, err := lb.sendListToServer(replica.server, put, "")
 		if err != nil {
+			fmt.Println("Error repairing server "+replica.server+":", err)
 			continue
 		}
+		if status != http.StatusOK {
+			fmt.Printf("Error repairing server %s: %d\n", replica.server, status)
+		}
+	}
+}
 
-		// Send a success response (HTTP 200 OK) to the client
-		w.WriteHeader(http.StatusOK)
-		return
+func (lb *LoadBalancer) withoutJoining(servers []string) []string {
+	lb.joiningLock.Lock()
+	defer lb.joiningLock.Unlock()
+	var ready []string
+	for _, server := range servers {
+		if !lb.joining[server] {
+			ready = append(ready, server)
+		}
+	}
+	return ready
+}
+
+type readResult struct {
+	server string
+	// nil when the replica does not have the list
+	list *crdt.List
+	err  error
+}
+
+// fetchListFromServer reads the shopping list for email from server, routed with the ring at epoch.
+// A server that does not have the list answers with no list and no error.
+func (lb *LoadBalancer) fetchListFromServer(server, email string, epoch uint64) (*crdt.List, error) {
+	response, status, err := lb.Transport.GetList(server, email, epoch)
+	lb.countRequest(server, false, err != nil || (status != http.StatusOK && status != http.StatusNotFound))
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusConflict {
+		return nil, errStaleRoute
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
 	}
 
-	// If the request was not successful, send an error response (HTTP 500 Internal Server Error) to the client
-	http.Error(w, "Error getting shopping list from server", http.StatusInternalServerError)
+	// Check the response status code
+	if status != http.StatusOK {
+		return nil, message.UnexpectedStatus(server, status)
+	}
+	return crdt.FromGOB64(response.List), nil
 }
 
 func main() {
-	loadBalancer := NewLoadBalancer()
+	port := flag.String("port", "8080", "port the load balancer listens on, gRPC listens on the port plus 1000")
+	seeds := flag.String("servers", "", "comma separated addresses of servers to learn the ring from at start")
+	writeQuorum := flag.Int("w", 2, "number of replicas that must acknowledge a write")
+	readQuorum := flag.Int("r", 2, "number of replicas that are read and merged on a read")
+	transportName := flag.String("transport", "http", "transport used to talk to the servers, http or grpc")
+	virtualNodes := flag.Int("vnodes", consistent.DefaultRingConfig().VirtualNodes, "number of virtual nodes of every server joining through this load balancer")
+	replicationFactor := flag.Int("rf", consistent.DefaultRingConfig().ReplicationFactor, "number of replicas after the owner of every key, when this load balancer admits the first server")
+	hash := flag.String("hash", "sha256", "hash function placing keys on the ring, sha256, xxhash or murmur3, when this load balancer admits the first server")
+	partitionerName := flag.String("partitioner", "ring", "partitioner placing keys, ring, rendezvous, jump or bounded, when this load balancer admits the first server")
+	statePath := flag.String("state", "", "file the membership is cached in, ../node_storage/load_balancer_<port>.json when not set, none when empty")
+	dryRun := flag.Bool("dry-run", false, "print the key ranges that would move if the servers of -add joined and the servers of -remove left, then exit")
+	add := flag.String("add", "", "comma separated servers a dry run adds, as id=address or id=address@weight")
+	remove := flag.String("remove", "", "comma separated ids of the servers a dry run removes")
+	flag.Parse()
+	stateSet := false
+	flag.Visit(func(f *flag.Flag) {
+		stateSet = stateSet || f.Name == "state"
+	})
+	if !stateSet {
+		*statePath = "../node_storage/load_balancer_" + *port + ".json"
+	}
+
+	hasher, err := consistent.NewHasher(*hash)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config := consistent.RingConfig{VirtualNodes: *virtualNodes, ReplicationFactor: *replicationFactor, Hasher: hasher}
+	err = config.Validate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	partitioner, err := consistent.NewPartitioner(*partitionerName, config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	transport, err := rpc.NewTransport(*transportName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	loadBalancer := NewLoadBalancer(partitioner, config, *writeQuorum, *readQuorum, transport)
+	if *seeds != "" {
+		loadBalancer.Seeds = strings.Split(*seeds, ",")
+	}
+	loadBalancer.StatePath = *statePath
+	if *statePath != "" {
+		err = loadBalancer.loadMembership()
+		if err != nil {
+			log.Fatal("Error restoring the membership: ", err)
+		}
+	}
+	if *dryRun {
+		plan, err := parsePlan(*add, *remove)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// a dry run changes nothing, not even the cached membership
+		loadBalancer.StatePath = ""
+		// without a cached membership the ring is learnt from the seeds
+		if members, _ := loadBalancer.Members.Ring(); len(members) == 0 {
+			loadBalancer.gossip()
+		}
+		err = loadBalancer.printRebalance(plan)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	replicas := 1 + loadBalancer.Partitioner().ReplicationFactor()
+	if *writeQuorum < 1 || *writeQuorum > replicas {
+		log.Fatalf("write quorum must be between 1 and %d", replicas)
+	}
+	if *readQuorum < 1 || *readQuorum > replicas {
+		log.Fatalf("read quorum must be between 1 and %d", replicas)
+	}
 
 	// Set up HTTP handler for load balancer
 	http.HandleFunc("/connect-node", loadBalancer.HandleNodeConnection)
+	http.HandleFunc("/disconnect-node", loadBalancer.HandleNodeDisconnection)
+	http.HandleFunc("/set-weight", loadBalancer.HandleSetWeight)
+	http.HandleFunc("/admin/rebalance", loadBalancer.HandleRebalancePlan)
+	http.HandleFunc("/admin/nodes", loadBalancer.HandleRingNodes)
+	http.HandleFunc("/admin/owners/", loadBalancer.HandleKeyOwners)
+	http.HandleFunc("/admin/requests", loadBalancer.HandleRequestCounts)
 	http.HandleFunc("/putList", loadBalancer.HandleShoppingListPut)
 	http.HandleFunc("/list/", loadBalancer.HandleShoppingListGet)
-	// Start the load balancer on port 8080
-	fmt.Println("Load balancer listening on port 8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	// the same requests over gRPC
+	rpcListener, err := rpc.Listen(":" + *port)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		log.Fatal(rpc.NewLoadBalancerServer(loadBalancer).Serve(rpcListener))
+	}()
+	// learn the ring and the heartbeats of the servers
+	go func() {
+		for {
+			loadBalancer.gossip()
+			loadBalancer.Detector.Check(time.Now())
+			time.Sleep(gossipInterval)
+		}
+	}()
+	fmt.Println("Load balancer listening on port " + *port)
+	log.Fatal(http.ListenAndServe(":"+*port, nil))
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"CloudShoppingList/consistent_hashing"
+	"CloudShoppingList/crdt"
+	"CloudShoppingList/message"
+	"CloudShoppingList/rpc"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTransport answers reads from lists kept by server and records the writes. A lagging
+// server answers right after the others, while the read is being answered.
+type fakeTransport struct {
+	rpc.Transport
+	lists   map[string]*crdt.List
+	lagging string
+	others  sync.WaitGroup
+	mutex   sync.Mutex
+	puts    map[string][]*message.PutList
+}
+
+func (transport *fakeTransport) GetList(server string, email string, epoch uint64) (*message.ShoppingList, int, error) {
+	if server == transport.lagging {
+		transport.others.Wait()
+	} else {
+		defer transport.others.Done()
+	}
+	list, exists := transport.lists[server]
+	if !exists {
+		return nil, http.StatusNotFound, nil
+	}
+	return &message.ShoppingList{Email: email, List: list.ToGOB64()}, http.StatusOK, nil
+}
+
+func (transport *fakeTransport) PutList(server string, request *message.PutList) (int, error) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.puts[server] = append(transport.puts[server], request)
+	return http.StatusOK, nil
+}
+
+func (transport *fakeTransport) repaired(server string) int {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	return len(transport.puts[server])
+}
+
+// newTestBalancer routes with a ring of servers s1, s2 and s3, keeping every key on all three.
+func newTestBalancer(transport rpc.Transport) *LoadBalancer {
+	config := consistent.DefaultRingConfig()
+	config.ReplicationFactor = 2
+	lb := NewLoadBalancer(consistent.NewRing(config), config, 2, 2, transport)
+	var members []consistent.Member
+	for _, id := range []string{"s1", "s2", "s3"} {
+		members = append(members, consistent.Member{ID: id, Server: id, VirtualNodes: config.VirtualNodes})
+	}
+	lb.Partitioner().Restore(members, 1)
+	return lb
+}
+
+func TestReadRepairOfALaggingReplicaDoesNotRace(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		transport := &fakeTransport{lists: make(map[string]*crdt.List), puts: make(map[string][]*message.PutList)}
+		lb := newTestBalancer(transport)
+		servers, err := lb.Partitioner().GetNodeAndReplicas("milk@example.com")
+		if err != nil || len(servers) != 3 {
+			t.Fatalf("servers %v: %v", servers, err)
+		}
+		// the lagging replica holds an item the others miss, so the repair merges it
+		for i, server := range servers {
+			list := crdt.NewList(server)
+			list.Increment("milk")
+			if i == 2 {
+				list.Increment("eggs")
+			}
+			transport.lists[server] = list
+		}
+		transport.lagging = servers[2]
+		transport.others.Add(2)
+
+		list, err := lb.getList("milk@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(crdt.FromGOB64(list.List).Data) == 0 {
+			t.Fatalf("read an empty list")
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for transport.repaired(servers[0])+transport.repaired(servers[1]) < 2 {
+			if time.Now().After(deadline) {
+				t.Fatalf("the replicas behind the lagging one were not repaired")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
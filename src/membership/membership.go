@@ -0,0 +1,233 @@
+// Package membership keeps the list of servers of the ring. The servers gossip it to each
+// other, and the load balancers read the ring from it.
+package membership
+
+import (
+	"CloudShoppingList/consistent_hashing"
+	"CloudShoppingList/message"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// List is the record of every server known to be or to have been in the ring. Records are
+// never dropped, a server that left stays with status Left so older records of it lose.
+type List struct {
+	sync.RWMutex
+	members           map[string]message.Member
+	replicationFactor int
+	hash              string
+	partitioner       string
+}
+
+// ErrHashMismatch is returned by Merge for the membership of a ring hashing keys with another
+// function. Lists are stored under the hash of their email, so a ring never changes it.
+var ErrHashMismatch = errors.New("the ring hashes keys with another function")
+
+// ErrPartitionerMismatch is returned by Merge for the membership of a ring placing keys with
+// another partitioner. The servers hold the ranges of their partitioner, so a ring never changes it.
+var ErrPartitionerMismatch = errors.New("the ring places keys with another partitioner")
+
+func NewList() *List {
+	return &List{members: make(map[string]message.Member)}
+}
+
+// Merge takes in the records of membership that are newer than the known ones, and its
+// replication factor, hash function and partitioner when none is known. It reports whether the
+// ring changed, and the ids of the known servers whose heartbeat went up. A membership hashing
+// keys with another function or placing them with another partitioner than the known ones is
+// refused as a whole.
+func (l *List) Merge(membership *message.Membership) (bool, []string, error) {
+	l.Lock()
+	defer l.Unlock()
+	if membership.Hash != "" && l.hash != "" && membership.Hash != l.hash {
+		return false, nil, fmt.Errorf("%w: %s, not %s", ErrHashMismatch, l.hash, membership.Hash)
+	}
+	if membership.Partitioner != "" && l.partitioner != "" && membership.Partitioner != l.partitioner {
+		return false, nil, fmt.Errorf("%w: %s, not %s", ErrPartitionerMismatch, l.partitioner, membership.Partitioner)
+	}
+	changed := false
+	// the replication factor is set once for the whole ring, two load balancers setting
+	// different ones at once agree on the larger
+	if membership.ReplicationFactor > l.replicationFactor {
+		l.replicationFactor = membership.ReplicationFactor
+		changed = true
+	}
+	// the hash function is kept for good once known
+	if membership.Hash != "" && l.hash == "" {
+		l.hash = membership.Hash
+		changed = true
+	}
+	if membership.Partitioner != "" && l.partitioner == "" {
+		l.partitioner = membership.Partitioner
+		changed = true
+	}
+	var beating []string
+	for _, member := range membership.Members {
+		known, exists := l.members[member.ID]
+		if !exists {
+			l.members[member.ID] = member
+			changed = true
+			continue
+		}
+		if member.Heartbeat > known.Heartbeat {
+			known.Heartbeat = member.Heartbeat
+			beating = append(beating, member.ID)
+		}
+		if member.Version > known.Version {
+			// the newer record replaces the known one whole, keeping the highest heartbeat
+			member.Heartbeat = known.Heartbeat
+			known = member
+			changed = true
+		}
+		l.members[member.ID] = known
+	}
+	return changed, beating, nil
+}
+
+// Update changes the record of the server id, which has to be known, as a new version of it.
+func (l *List) Update(id string, change func(member *message.Member)) bool {
+	l.Lock()
+	defer l.Unlock()
+	member, exists := l.members[id]
+	if !exists {
+		return false
+	}
+	change(&member)
+	member.Version++
+	l.members[id] = member
+	return true
+}
+
+// Beat raises the heartbeat of the server id.
+func (l *List) Beat(id string) {
+	l.Lock()
+	defer l.Unlock()
+	if member, exists := l.members[id]; exists {
+		member.Heartbeat++
+		l.members[id] = member
+	}
+}
+
+func (l *List) Get(id string) (message.Member, bool) {
+	l.RLock()
+	defer l.RUnlock()
+	member, exists := l.members[id]
+	return member, exists
+}
+
+// Membership returns the records ordered by id together with their epoch, taken at once.
+func (l *List) Membership() *message.Membership {
+	l.RLock()
+	defer l.RUnlock()
+	membership := &message.Membership{Epoch: l.epoch(), ReplicationFactor: l.replicationFactor, Hash: l.hash, Partitioner: l.partitioner}
+	for _, member := range l.members {
+		membership.Members = append(membership.Members, member)
+	}
+	sort.Slice(membership.Members, func(i, j int) bool {
+		return membership.Members[i].ID < membership.Members[j].ID
+	})
+	return membership
+}
+
+// Hash is the name of the hash function placing keys on the ring, empty while unknown.
+func (l *List) Hash() string {
+	l.RLock()
+	defer l.RUnlock()
+	return l.hash
+}
+
+// Partitioner is the name of the partitioner placing keys on the ring, empty while unknown.
+func (l *List) Partitioner() string {
+	l.RLock()
+	defer l.RUnlock()
+	return l.partitioner
+}
+
+// ReplicationFactor is the number of replicas after the owner of every key, zero while unknown.
+func (l *List) ReplicationFactor() int {
+	l.RLock()
+	defer l.RUnlock()
+	return l.replicationFactor
+}
+
+// Epoch is the version of the ring. Every change of a record raises the version of the
+// record and versions only go up, so their sum grows on every change wherever it is computed.
+// The replication factor only goes up and the hash function and partitioner are set once, they
+// count too as they place the keys anew.
+func (l *List) Epoch() uint64 {
+	l.RLock()
+	defer l.RUnlock()
+	return l.epoch()
+}
+
+func (l *List) epoch() uint64 {
+	epoch := uint64(l.replicationFactor)
+	if l.hash != "" {
+		epoch++
+	}
+	if l.partitioner != "" {
+		epoch++
+	}
+	for _, member := range l.members {
+		epoch += member.Version
+	}
+	return epoch
+}
+
+// Ring returns the servers that have not left, as members of a consistent hashing ring,
+// together with the epoch.
+func (l *List) Ring() ([]consistent.Member, uint64) {
+	membership := l.Membership()
+	var members []consistent.Member
+	for _, member := range membership.Members {
+		if member.Status != message.Left {
+			members = append(members, consistent.Member{ID: member.ID, Server: member.Address, VirtualNodes: member.VirtualNodes, Zone: member.Zone})
+		}
+	}
+	return members, membership.Epoch
+}
+
+// saved is what Save writes of a List.
+type saved struct {
+	ReplicationFactor int              `json:"replication_factor,omitempty"`
+	Hash              string           `json:"hash,omitempty"`
+	Partitioner       string           `json:"partitioner,omitempty"`
+	Members           []message.Member `json:"members"`
+}
+
+// Save writes the records to path, replacing the file at once.
+func (l *List) Save(path string) error {
+	membership := l.Membership()
+	data, err := json.MarshalIndent(saved{ReplicationFactor: membership.ReplicationFactor, Hash: membership.Hash, Partitioner: membership.Partitioner, Members: membership.Members}, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporary := path + ".tmp"
+	err = os.WriteFile(temporary, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
+
+// Load merges the records saved at path, there are none when the file does not exist.
+func (l *List) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var state saved
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return err
+	}
+	_, _, err = l.Merge(&message.Membership{ReplicationFactor: state.ReplicationFactor, Hash: state.Hash, Partitioner: state.Partitioner, Members: state.Members})
+	return err
+}
@@ -0,0 +1,196 @@
+package membership
+
+import (
+	"CloudShoppingList/message"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func member(id string, status string, version, heartbeat uint64) message.Member {
+	return message.Member{ID: id, Address: "localhost:" + id, VirtualNodes: 3, Status: status, Version: version, Heartbeat: heartbeat}
+}
+
+func TestMergeKeepsTheNewestVersion(t *testing.T) {
+	list := NewList()
+	changed, _, _ := list.Merge(&message.Membership{Members: []message.Member{member("a", message.Joining, 1, 0)}})
+	if !changed {
+		t.Error("a new server does not change the ring")
+	}
+	changed, _, _ = list.Merge(&message.Membership{Members: []message.Member{member("a", message.Ready, 2, 0)}})
+	if !changed {
+		t.Error("a newer version does not change the ring")
+	}
+	changed, _, _ = list.Merge(&message.Membership{Members: []message.Member{member("a", message.Joining, 1, 0)}})
+	if changed {
+		t.Error("an older version changed the ring")
+	}
+	if got, _ := list.Get("a"); got.Status != message.Ready || got.Version != 2 {
+		t.Errorf("got %s at version %d, want ready at version 2", got.Status, got.Version)
+	}
+}
+
+func TestMergeTakesTheWholeNewerRecord(t *testing.T) {
+	list := NewList()
+	list.Merge(&message.Membership{Members: []message.Member{member("a", message.Ready, 1, 4)}})
+	// the server changed its weight and zone, as after /set-weight
+	reweighted := member("a", message.Ready, 2, 3)
+	reweighted.Weight, reweighted.VirtualNodes, reweighted.Zone = 2, 7, "rack-1"
+	changed, _, _ := list.Merge(&message.Membership{Members: []message.Member{reweighted}})
+	if !changed {
+		t.Error("a new weight does not change the ring")
+	}
+	got, _ := list.Get("a")
+	if got.Weight != 2 || got.VirtualNodes != 7 || got.Zone != "rack-1" || got.Version != 2 {
+		t.Errorf("got %+v, want weight 2 with 7 virtual nodes in rack-1 at version 2", got)
+	}
+	if got.Heartbeat != 4 {
+		t.Errorf("heartbeat went back to %d", got.Heartbeat)
+	}
+	members, _ := list.Ring()
+	if members[0].VirtualNodes != 7 || members[0].Zone != "rack-1" {
+		t.Errorf("ring has %+v", members[0])
+	}
+}
+
+func TestMergeReportsRisingHeartbeats(t *testing.T) {
+	list := NewList()
+	list.Merge(&message.Membership{Members: []message.Member{member("a", message.Ready, 1, 5), member("b", message.Ready, 1, 5)}})
+	changed, beating, _ := list.Merge(&message.Membership{Members: []message.Member{member("a", message.Ready, 1, 6), member("b", message.Ready, 1, 4)}})
+	if changed {
+		t.Error("a heartbeat changed the ring")
+	}
+	if want := []string{"a"}; !reflect.DeepEqual(beating, want) {
+		t.Errorf("got %v, want %v", beating, want)
+	}
+	if got, _ := list.Get("b"); got.Heartbeat != 5 {
+		t.Errorf("heartbeat went back to %d", got.Heartbeat)
+	}
+}
+
+func TestListsConvergeWhateverTheOrder(t *testing.T) {
+	updates := [][]message.Member{
+		{member("a", message.Joining, 1, 0)},
+		{member("b", message.Joining, 1, 3)},
+		{member("a", message.Ready, 2, 7)},
+		{member("b", message.Ready, 2, 4), member("c", message.Joining, 1, 0)},
+		{member("a", message.Left, 3, 9)},
+	}
+	forward, backward := NewList(), NewList()
+	for i := range updates {
+		forward.Merge(&message.Membership{Members: updates[i]})
+		backward.Merge(&message.Membership{Members: updates[len(updates)-1-i]})
+	}
+	if !reflect.DeepEqual(forward.Membership(), backward.Membership()) {
+		t.Fatalf("lists differ:\n%v\n%v", forward.Membership(), backward.Membership())
+	}
+	if epoch := forward.Epoch(); epoch != 6 {
+		t.Errorf("got epoch %d, want 6", epoch)
+	}
+	members, _ := forward.Ring()
+	var ids []string
+	for _, member := range members {
+		ids = append(ids, member.ID)
+	}
+	if want := []string{"b", "c"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ring has %v, want %v", ids, want)
+	}
+}
+
+func TestUpdateRaisesTheEpoch(t *testing.T) {
+	list := NewList()
+	list.Merge(&message.Membership{Members: []message.Member{member("a", message.Joining, 1, 0)}})
+	before := list.Epoch()
+	if !list.Update("a", func(member *message.Member) { member.Status = message.Ready }) {
+		t.Fatal("known server was not updated")
+	}
+	if list.Epoch() <= before {
+		t.Errorf("epoch went from %d to %d", before, list.Epoch())
+	}
+	if list.Update("b", func(member *message.Member) {}) {
+		t.Error("unknown server was updated")
+	}
+}
+
+func TestReplicationFactorIsSetOnce(t *testing.T) {
+	list := NewList()
+	list.Merge(&message.Membership{Members: []message.Member{member("a", message.Ready, 1, 0)}})
+	if rf := list.ReplicationFactor(); rf != 0 {
+		t.Fatalf("got replication factor %d before any was gossiped", rf)
+	}
+	changed, _, _ := list.Merge(&message.Membership{ReplicationFactor: 2})
+	if !changed || list.ReplicationFactor() != 2 {
+		t.Errorf("replication factor 2 not taken, changed %v, got %d", changed, list.ReplicationFactor())
+	}
+	changed, _, _ = list.Merge(&message.Membership{ReplicationFactor: 1})
+	if changed || list.ReplicationFactor() != 2 {
+		t.Errorf("smaller replication factor taken, changed %v, got %d", changed, list.ReplicationFactor())
+	}
+	// a new replication factor places the keys anew
+	epoch := list.Epoch()
+	list.Merge(&message.Membership{ReplicationFactor: 3})
+	if list.Epoch() <= epoch {
+		t.Errorf("replication factor 3 kept epoch %d", epoch)
+	}
+	list.Merge(&message.Membership{})
+	if rf := list.Membership().ReplicationFactor; rf != 3 {
+		t.Errorf("membership has replication factor %d, want 3", rf)
+	}
+}
+
+func TestHashFunctionIsSetOnce(t *testing.T) {
+	list := NewList()
+	list.Merge(&message.Membership{Hash: "xxhash"})
+	changed, _, _ := list.Merge(&message.Membership{})
+	if changed || list.Hash() != "xxhash" {
+		t.Errorf("hash function lost, changed %v, got %q", changed, list.Hash())
+	}
+	// the lists of the ring are stored under their xxhash, another function is refused
+	changed, _, err := list.Merge(&message.Membership{Hash: "sha256", Members: []message.Member{member("a", message.Ready, 1, 0)}})
+	if !errors.Is(err, ErrHashMismatch) || changed {
+		t.Errorf("sha256 membership merged, changed %v, error %v", changed, err)
+	}
+	if _, exists := list.Get("a"); exists || list.Hash() != "xxhash" {
+		t.Errorf("got hash function %q and the records of a sha256 ring", list.Hash())
+	}
+}
+
+func TestPartitionerIsSetOnce(t *testing.T) {
+	list := NewList()
+	epoch := list.Epoch()
+	list.Merge(&message.Membership{Partitioner: "rendezvous"})
+	if list.Partitioner() != "rendezvous" || list.Epoch() != epoch+1 {
+		t.Errorf("got partitioner %q at epoch %d", list.Partitioner(), list.Epoch())
+	}
+	// the servers hold the ranges of rendezvous hashing, another partitioner is refused
+	changed, _, err := list.Merge(&message.Membership{Partitioner: "ring", Members: []message.Member{member("a", message.Ready, 1, 0)}})
+	if !errors.Is(err, ErrPartitionerMismatch) || changed {
+		t.Errorf("ring membership merged, changed %v, error %v", changed, err)
+	}
+	if _, exists := list.Get("a"); exists || list.Partitioner() != "rendezvous" {
+		t.Errorf("got partitioner %q and the records of a ring", list.Partitioner())
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "membership.json")
+	list := NewList()
+	list.Merge(&message.Membership{ReplicationFactor: 1, Hash: "xxhash", Partitioner: "jump", Members: []message.Member{member("a", message.Ready, 2, 8), member("b", message.Left, 3, 1)}})
+	err := list.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewList()
+	err = loaded.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Membership(), list.Membership()) {
+		t.Errorf("got %v, want %v", loaded.Membership(), list.Membership())
+	}
+	err = NewList().Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Errorf("missing file: %v", err)
+	}
+}
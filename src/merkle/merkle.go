@@ -0,0 +1,215 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// Tree is a binary Merkle tree over the hashed keys of a range (start, end] of the ring.
+// The range is cut in 2^depth leaves of the same width and a key goes to the leaf of its
+// offset from start, so two trees built with the same depth and range can be compared
+// node by node. Nodes are numbered like a heap: 1 is the root and the
+// children of node i are 2i and 2i+1. A Tree is safe for concurrent use.
+type Tree struct {
+	sync.Mutex
+	depth  int
+	start  string
+	end    string
+	leaves []map[string][]byte
+	hashes [][]byte
+	dirty  []bool
+}
+
+// NewTree returns an empty tree of 2^depth leaves over the range (start, end], the whole
+// ring when start is end.
+func NewTree(depth int, start, end string) *Tree {
+	size := 1 << (depth + 1)
+	tree := &Tree{
+		depth:  depth,
+		start:  start,
+		end:    end,
+		leaves: make([]map[string][]byte, 1<<depth),
+		hashes: make([][]byte, size),
+		dirty:  make([]bool, size),
+	}
+	for i := range tree.leaves {
+		tree.leaves[i] = make(map[string][]byte)
+	}
+	return tree
+}
+
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Put sets the digest of the contents stored under key.
+func (t *Tree) Put(key string, digest []byte) {
+	t.Lock()
+	defer t.Unlock()
+	leaf := t.Leaf(key)
+	t.leaves[leaf-(1<<t.depth)][key] = digest
+	t.invalidate(leaf)
+}
+
+func (t *Tree) Delete(key string) {
+	t.Lock()
+	defer t.Unlock()
+	leaf := t.Leaf(key)
+	delete(t.leaves[leaf-(1<<t.depth)], key)
+	t.invalidate(leaf)
+}
+
+// Leaf returns the index of the leaf that holds key. Keys are read as big endian numbers,
+// the shorter ones padded with zeros, so the leaves follow the order of the ring.
+func (t *Tree) Leaf(key string) int {
+	size := len(key)
+	if len(t.start) > size {
+		size = len(t.start)
+	}
+	if len(t.end) > size {
+		size = len(t.end)
+	}
+	ring := new(big.Int).Lsh(big.NewInt(1), uint(8*size))
+	start := number(t.start, size)
+	// offset is in (0, width] for the keys of the range
+	offset := new(big.Int).Sub(number(key, size), start)
+	offset.Mod(offset, ring)
+	if offset.Sign() == 0 {
+		offset.Set(ring)
+	}
+	width := new(big.Int).Sub(number(t.end, size), start)
+	width.Mod(width, ring)
+	if width.Sign() == 0 {
+		width.Set(ring)
+	}
+	leaves := 1 << t.depth
+	leaf := offset.Sub(offset, big.NewInt(1))
+	leaf.Lsh(leaf, uint(t.depth)).Div(leaf, width)
+	if !leaf.IsInt64() || leaf.Int64() >= int64(leaves) {
+		// a key outside the range
+		return 2*leaves - 1
+	}
+	return leaves + int(leaf.Int64())
+}
+
+// number reads key as a big endian number of size bytes.
+func number(key string, size int) *big.Int {
+	padded := make([]byte, size)
+	copy(padded, key)
+	return new(big.Int).SetBytes(padded)
+}
+
+// IsLeaf reports whether index is a leaf of the tree.
+func (t *Tree) IsLeaf(index int) bool {
+	return index >= 1<<t.depth
+}
+
+// Valid reports whether index is a node of the tree.
+func (t *Tree) Valid(index int) bool {
+	return index >= 1 && index < len(t.hashes)
+}
+
+func (t *Tree) Children(index int) (int, int) {
+	return 2 * index, 2*index + 1
+}
+
+// Digests returns the keys, and their digests, under the leaf index.
+func (t *Tree) Digests(index int) map[string][]byte {
+	t.Lock()
+	defer t.Unlock()
+	digests := make(map[string][]byte)
+	for key, digest := range t.leaves[index-(1<<t.depth)] {
+		digests[key] = digest
+	}
+	return digests
+}
+
+func (t *Tree) Root() []byte {
+	return t.Hash(1)
+}
+
+// Hash returns the hash of node index. Empty subtrees hash to an empty slice.
+func (t *Tree) Hash(index int) []byte {
+	t.Lock()
+	defer t.Unlock()
+	return t.hash(index)
+}
+
+func (t *Tree) hash(index int) []byte {
+	if !t.dirty[index] {
+		return t.hashes[index]
+	}
+	var hash []byte
+	if t.IsLeaf(index) {
+		leaf := t.leaves[index-(1<<t.depth)]
+		if len(leaf) != 0 {
+			keys := make([]string, 0, len(leaf))
+			for key := range leaf {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			h := sha256.New()
+			for _, key := range keys {
+				h.Write([]byte(key))
+				h.Write(leaf[key])
+			}
+			hash = h.Sum(nil)
+		}
+	} else {
+		left, right := t.Children(index)
+		leftHash, rightHash := t.hash(left), t.hash(right)
+		if len(leftHash) != 0 || len(rightHash) != 0 {
+			h := sha256.New()
+			h.Write(leftHash)
+			h.Write(rightHash)
+			hash = h.Sum(nil)
+		}
+	}
+	t.hashes[index] = hash
+	t.dirty[index] = false
+	return hash
+}
+
+// Equal reports whether node index of the tree has the given hash.
+func (t *Tree) Equal(index int, hash []byte) bool {
+	return bytes.Equal(t.Hash(index), hash)
+}
+
+// DifferingLeaves walks down the tree from the root, descending only into the nodes whose
+// hashes differ from another tree, and returns the leaves that differ. compare gets the
+// nodes of a level and returns the ones whose hashes differ on the other side.
+func (t *Tree) DifferingLeaves(compare func(level []int) ([]int, error)) ([]int, error) {
+	level := []int{1}
+	for {
+		differing, err := compare(level)
+		if err != nil {
+			return nil, err
+		}
+		if len(differing) == 0 {
+			return nil, nil
+		}
+		for _, index := range differing {
+			if !t.Valid(index) {
+				return nil, fmt.Errorf("invalid tree node %d", index)
+			}
+		}
+		if t.IsLeaf(differing[0]) {
+			return differing, nil
+		}
+		level = nil
+		for _, index := range differing {
+			left, right := t.Children(index)
+			level = append(level, left, right)
+		}
+	}
+}
+
+func (t *Tree) invalidate(index int) {
+	for ; index >= 1; index /= 2 {
+		t.dirty[index] = true
+	}
+}
@@ -0,0 +1,168 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// key hashes name the way the servers hash emails, so keys spread over the leaves.
+func key(name string) string {
+	hash := sha256.Sum256([]byte(name))
+	return string(hash[:])
+}
+
+// compareWith answers the walk of DifferingLeaves over local the way a peer holding other does.
+func compareWith(local, other *Tree) func(level []int) ([]int, error) {
+	return func(level []int) ([]int, error) {
+		var differing []int
+		for _, index := range level {
+			if !other.Equal(index, local.Hash(index)) {
+				differing = append(differing, index)
+			}
+		}
+		return differing, nil
+	}
+}
+
+func TestEmptyTreeHasEmptyRoot(t *testing.T) {
+	tree := NewTree(4, "", "")
+	if len(tree.Root()) != 0 {
+		t.Fatalf("empty tree has root %x", tree.Root())
+	}
+}
+
+func TestPutAndDeleteChangeTheRoot(t *testing.T) {
+	tree := NewTree(4, "", "")
+	tree.Put(key("milk"), []byte("1"))
+	root := tree.Root()
+	if len(root) == 0 {
+		t.Fatalf("a tree with a key has an empty root")
+	}
+
+	tree.Put(key("milk"), []byte("2"))
+	if bytes.Equal(tree.Root(), root) {
+		t.Fatalf("a new digest left the root unchanged")
+	}
+	tree.Put(key("milk"), []byte("1"))
+	if !bytes.Equal(tree.Root(), root) {
+		t.Fatalf("putting the first digest back did not restore the root")
+	}
+
+	tree.Delete(key("milk"))
+	if len(tree.Root()) != 0 {
+		t.Fatalf("deleting the only key left root %x", tree.Root())
+	}
+}
+
+func TestRootDoesNotDependOnOrder(t *testing.T) {
+	names := []string{"milk", "eggs", "rice", "bread", "beans"}
+	forward, backward := NewTree(3, "", ""), NewTree(3, "", "")
+	for i := range names {
+		forward.Put(key(names[i]), []byte(names[i]))
+		backward.Put(key(names[len(names)-1-i]), []byte(names[len(names)-1-i]))
+	}
+	if !bytes.Equal(forward.Root(), backward.Root()) {
+		t.Fatalf("the same keys put in another order give another root")
+	}
+	if !forward.Equal(1, backward.Root()) {
+		t.Fatalf("Equal disagrees with the roots")
+	}
+	backward.Delete(key("rice"))
+	if forward.Equal(1, backward.Root()) {
+		t.Fatalf("trees with different keys are equal")
+	}
+}
+
+func TestLeafFollowsTheOffsetInTheRange(t *testing.T) {
+	tree := NewTree(3, "", "")
+	if leaf := tree.Leaf("\x01"); leaf != 8 {
+		t.Errorf("leaf of 0x01 is %d, want 8", leaf)
+	}
+	if leaf := tree.Leaf("\x00"); leaf != 15 {
+		t.Errorf("leaf of 0x00, the end of the ring, is %d, want 15", leaf)
+	}
+	if leaf := tree.Leaf("\xa1"); leaf != 13 {
+		t.Errorf("leaf of 0xa1 is %d, want 13", leaf)
+	}
+	if !tree.IsLeaf(8) || tree.IsLeaf(7) || tree.Valid(0) || tree.Valid(16) || !tree.Valid(15) {
+		t.Errorf("wrong leaves or nodes of a tree of depth 3")
+	}
+
+	tree.Put("\xa1", []byte("1"))
+	if digests := tree.Digests(13); len(digests) != 1 {
+		t.Errorf("leaf 13 holds %d keys, want 1", len(digests))
+	}
+
+	// (0xf0, 0x10] wraps around the ring and is cut in leaves of 4
+	wrapping := NewTree(3, "\xf0", "\x10")
+	for key, want := range map[string]int{"\xf1": 8, "\x00": 11, "\x01": 12, "\x10": 15} {
+		if leaf := wrapping.Leaf(key); leaf != want {
+			t.Errorf("leaf of %x in (f0, 10] is %d, want %d", key, leaf, want)
+		}
+	}
+}
+
+func TestNarrowRangeSpreadsOverTheLeaves(t *testing.T) {
+	// (0x1000.., 0x1080..] is 1/512 of the ring
+	start, end := "\x10\x00", "\x10\x80"
+	tree := NewTree(4, start, end)
+	for i := 0; i < 200; i++ {
+		hash := []byte(key(string(rune('a'+i%26)) + string(rune('a'+i/26))))
+		hash[0], hash[1] = 0x10, hash[1]&0x7f
+		tree.Put(string(hash), []byte("1"))
+	}
+	for leaf := 1 << tree.Depth(); tree.Valid(leaf); leaf++ {
+		if len(tree.Digests(leaf)) == 0 {
+			t.Errorf("leaf %d of a narrow range is empty", leaf)
+		}
+	}
+}
+
+func TestDifferingLeavesFindsTheChangedKeys(t *testing.T) {
+	local, peer := NewTree(6, "", ""), NewTree(6, "", "")
+	for i := 0; i < 200; i++ {
+		name := string(rune('a'+i%26)) + string(rune('a'+i/26))
+		local.Put(key(name), []byte(name))
+		peer.Put(key(name), []byte(name))
+	}
+	leaves, err := local.DifferingLeaves(compareWith(local, peer))
+	if err != nil || len(leaves) != 0 {
+		t.Fatalf("equal trees differ on leaves %v: %v", leaves, err)
+	}
+
+	changed := []string{"aa", "zb", "new"}
+	peer.Put(key("aa"), []byte("changed"))
+	peer.Delete(key("zb"))
+	local.Put(key("new"), []byte("new"))
+	leafSet := make(map[int]bool)
+	for _, name := range changed {
+		leafSet[local.Leaf(key(name))] = true
+	}
+	var want []int
+	for leaf := range leafSet {
+		want = append(want, leaf)
+	}
+	sort.Ints(want)
+
+	leaves, err = local.DifferingLeaves(compareWith(local, peer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(leaves)
+	if !reflect.DeepEqual(leaves, want) {
+		t.Fatalf("differing leaves %v, want %v", leaves, want)
+	}
+}
+
+func TestDifferingLeavesRejectsInvalidNodes(t *testing.T) {
+	tree := NewTree(2, "", "")
+	_, err := tree.DifferingLeaves(func(level []int) ([]int, error) {
+		return []int{99}, nil
+	})
+	if err == nil {
+		t.Fatalf("a node outside the tree was accepted")
+	}
+}
@@ -0,0 +1,487 @@
+package message
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Version of the protocol spoken between clients, load balancers and servers.
+// Messages carrying any other version are rejected.
+const Version = 1
+
+var ErrVersion = errors.New("unsupported protocol version")
+
+// Header is embedded in every message and carries the protocol version.
+type Header struct {
+	Version int `json:"version"`
+}
+
+func (h *Header) header() *Header {
+	return h
+}
+
+// Message is any of the request or response types of this package.
+type Message interface {
+	header() *Header
+}
+
+// Hash is a raw hash, such as a ring position or an email hash, encoded as hex in JSON.
+type Hash []byte
+
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(h)), nil
+}
+
+func (h *Hash) UnmarshalText(text []byte) error {
+	decoded, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	*h = decoded
+	return nil
+}
+
+// KeyRange is the range of hashes (Start, End] on the ring.
+type KeyRange struct {
+	Start Hash `json:"start"`
+	End   Hash `json:"end"`
+}
+
+// ErrorResponse is the body of every answer that is not 200 OK.
+type ErrorResponse struct {
+	Header
+	Error string `json:"error"`
+}
+
+// ConnectNode is sent by a server to a load balancer on /connect-node to join the ring,
+// it is answered with the membership the server starts gossiping from.
+// Weight is the capacity of the server relative to the others, 1 when not set. Zone is the
+// host, rack or data center of the server, the replicas of a key are spread over distinct zones.
+type ConnectNode struct {
+	Header
+	ID      string `json:"id"`
+	Address string `json:"address"`
+	Weight  int    `json:"weight,omitempty"`
+	Zone    string `json:"zone,omitempty"`
+	// Hash is the hash function of the ring the server was in before, empty for a new server
+	Hash string `json:"hash,omitempty"`
+	// Partitioner is the partitioner of the ring the server was in before, empty for a new server
+	Partitioner string `json:"partitioner,omitempty"`
+}
+
+// DisconnectNode asks a load balancer on /disconnect-node to decommission a server, and
+// the server itself on /leave to hand its keys over and leave the ring.
+type DisconnectNode struct {
+	Header
+	ID string `json:"id"`
+}
+
+// NodeWeight asks a load balancer on /set-weight to change the weight of a server, and the
+// server itself on /weight to take the VirtualNodes of the new weight, handing over or
+// receiving the ranges that move.
+type NodeWeight struct {
+	Header
+	ID           string `json:"id"`
+	Weight       int    `json:"weight"`
+	VirtualNodes int    `json:"virtual_nodes,omitempty"`
+}
+
+// RebalancePlan asks a load balancer on /admin/rebalance which key ranges would move if the
+// servers in Add joined the ring and the servers in Remove left it. Servers in Add that are
+// already in the ring would change their weight or zone.
+type RebalancePlan struct {
+	Header
+	Add    []PlannedNode `json:"add,omitempty"`
+	Remove []string      `json:"remove,omitempty"`
+}
+
+// PlannedNode is a server a RebalancePlan adds, as it would connect.
+type PlannedNode struct {
+	ID      string `json:"id"`
+	Address string `json:"address"`
+	Weight  int    `json:"weight,omitempty"`
+	Zone    string `json:"zone,omitempty"`
+}
+
+// Move is a key range a change of the ring copies from the server From to the server To.
+type Move struct {
+	Range KeyRange `json:"range"`
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+}
+
+// RebalanceMoves answers a RebalancePlan with the moves of the change to the ring at Epoch.
+type RebalanceMoves struct {
+	Header
+	Epoch uint64 `json:"epoch"`
+	Moves []Move `json:"moves"`
+}
+
+// RingNodes answers /admin/nodes with the servers of the ring at Epoch and their positions.
+type RingNodes struct {
+	Header
+	Epoch uint64     `json:"epoch"`
+	Nodes []RingNode `json:"nodes"`
+}
+
+// RingNode is a server of the ring as the load balancer sees it. Status is its status in the
+// membership and Health what the failure detector makes of its heartbeats: alive, suspect or dead.
+type RingNode struct {
+	ID        string         `json:"id"`
+	Address   string         `json:"address"`
+	Weight    int            `json:"weight"`
+	Zone      string         `json:"zone,omitempty"`
+	Status    string         `json:"status"`
+	Health    string         `json:"health"`
+	Positions []RingPosition `json:"positions"`
+}
+
+// RingPosition is the position of a server or of one of its virtual nodes on the ring. The front
+// neighbours replicate the range ending at the position, the back neighbours precede it.
+type RingPosition struct {
+	ID      string         `json:"id"`
+	Hash    Hash           `json:"hash"`
+	Virtual bool           `json:"virtual"`
+	Front   []RingNeighbor `json:"front"`
+	Back    []RingNeighbor `json:"back"`
+}
+
+// RingNeighbor is a neighbouring position, named by the server it belongs to.
+type RingNeighbor struct {
+	ID     string `json:"id"`
+	Hash   Hash   `json:"hash"`
+	Server string `json:"server"`
+}
+
+// KeyOwners answers /admin/owners/<email> with the servers holding the list of Email, the
+// owner first, as routed with the ring at Epoch.
+type KeyOwners struct {
+	Header
+	Email   string      `json:"email"`
+	Hash    Hash        `json:"hash"`
+	Epoch   uint64      `json:"epoch"`
+	Servers []KeyServer `json:"servers"`
+}
+
+// KeyServer is a server holding a key, with its health.
+type KeyServer struct {
+	ID      string `json:"id,omitempty"`
+	Address string `json:"address"`
+	Health  string `json:"health"`
+}
+
+// RequestCounts answers /admin/requests with the requests the load balancer sent every server
+// since it started.
+type RequestCounts struct {
+	Header
+	Nodes []NodeRequests `json:"nodes"`
+}
+
+// NodeRequests counts the writes and reads sent to a server, and those of them that failed.
+type NodeRequests struct {
+	ID       string `json:"id,omitempty"`
+	Address  string `json:"address"`
+	Puts     uint64 `json:"puts"`
+	Gets     uint64 `json:"gets"`
+	Failures uint64 `json:"failures"`
+}
+
+// Status of a server in the ring
+const (
+	// Joining servers take writes but no reads until they received their keys
+	Joining = "joining"
+	Ready   = "ready"
+	// Left servers handed their keys over and no longer belong to the ring
+	Left = "left"
+)
+
+// Member is what a server gossips about itself and the other servers of the ring.
+// Only the server itself changes its record, raising Version every time, so the record
+// with the highest Version is the latest. Heartbeat grows every gossip round while the
+// server is up and does not count as a change of the ring.
+type Member struct {
+	ID           string `json:"id"`
+	Address      string `json:"address"`
+	VirtualNodes int    `json:"virtual_nodes"`
+	Weight       int    `json:"weight,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	Status       string `json:"status"`
+	Version      uint64 `json:"version"`
+	Heartbeat    uint64 `json:"heartbeat"`
+}
+
+// Membership is the list of servers exchanged on /gossip, and answered to /connect-node.
+// Epoch is the version of the ring the members make up. ReplicationFactor is the number of
+// replicas after the owner of every key, Hash the hash function and Partitioner the partitioner
+// placing keys on the ring, all set by the load balancer that admitted the first server and
+// empty while unknown.
+type Membership struct {
+	Header
+	Epoch             uint64   `json:"epoch"`
+	ReplicationFactor int      `json:"replication_factor,omitempty"`
+	Hash              string   `json:"hash,omitempty"`
+	Partitioner       string   `json:"partitioner,omitempty"`
+	Members           []Member `json:"members"`
+}
+
+// PutList carries a shopping list, or a delta of it, on /putList and /putListServer.
+// A non-empty Hint names the replica the list is meant for when the receiver only keeps it on its behalf.
+// Epoch is the version of the ring the load balancer routed the list with, zero when it was not routed.
+type PutList struct {
+	Header
+	Email string `json:"email"`
+	List  string `json:"list"`
+	Delta bool   `json:"delta"`
+	Hint  string `json:"hint,omitempty"`
+	Epoch uint64 `json:"epoch,omitempty"`
+}
+
+// ShoppingList answers reads on /list/ and /getListServer/.
+type ShoppingList struct {
+	Header
+	Email string `json:"email"`
+	List  string `json:"list"`
+}
+
+// KeyTransfer asks a server on /sendMeKeys to send the lists in Range to the server at To.
+type KeyTransfer struct {
+	Header
+	To    string   `json:"to"`
+	Range KeyRange `json:"range"`
+}
+
+// FetchKeys asks a server on /fetchKeys for the next lists of Range, at most Limit of them,
+// starting right after the hash After. An empty After starts at the beginning of Range.
+type FetchKeys struct {
+	Header
+	Range KeyRange `json:"range"`
+	After Hash     `json:"after,omitempty"`
+	Limit int      `json:"limit"`
+}
+
+// StoredList is a shopping list as a server stores it.
+type StoredList struct {
+	Email     string `json:"email"`
+	EmailHash Hash   `json:"email_hash"`
+	List      string `json:"list"`
+}
+
+// KeyBatch answers FetchKeys with lists in ring order. Done is set once the last list of the range was sent,
+// otherwise the next batch starts after the hash of the last list.
+type KeyBatch struct {
+	Header
+	Lists []StoredList `json:"lists"`
+	Done  bool         `json:"done"`
+}
+
+// TreeNode is the hash of one node of a Merkle tree.
+type TreeNode struct {
+	Index int  `json:"index"`
+	Hash  Hash `json:"hash"`
+}
+
+// SyncTree sends the hashes of one level of the Merkle tree of Range on /syncTree.
+// Epoch is the version of the ring the sender took Range from.
+type SyncTree struct {
+	Header
+	Epoch uint64     `json:"epoch"`
+	Range KeyRange   `json:"range"`
+	Nodes []TreeNode `json:"nodes"`
+}
+
+// SyncTreeResponse lists the nodes whose hashes differ.
+type SyncTreeResponse struct {
+	Header
+	Differing []int `json:"differing"`
+}
+
+// ListDigest is the digest and causal context of one shopping list of a Merkle tree leaf.
+type ListDigest struct {
+	EmailHash Hash   `json:"email_hash"`
+	Digest    Hash   `json:"digest"`
+	Context   string `json:"context"`
+}
+
+// SyncKeys sends the digests of the lists under the differing Leaves of Range on /syncKeys.
+type SyncKeys struct {
+	Header
+	Epoch   uint64       `json:"epoch"`
+	Range   KeyRange     `json:"range"`
+	Leaves  []int        `json:"leaves"`
+	Digests []ListDigest `json:"digests"`
+}
+
+// WantedList asks for a shopping list, as a delta of Context or in full when Context is empty.
+type WantedList struct {
+	EmailHash Hash   `json:"email_hash"`
+	Context   string `json:"context,omitempty"`
+}
+
+// SyncKeysResponse carries the lists the receiver holds differently and the ones it wants back.
+type SyncKeysResponse struct {
+	Header
+	Lists  []SyncList   `json:"lists"`
+	Wanted []WantedList `json:"wanted"`
+}
+
+// SyncList is one shopping list exchanged during anti-entropy, in full or as a delta,
+// together with the full causal context of the sender.
+type SyncList struct {
+	Email     string `json:"email"`
+	EmailHash Hash   `json:"email_hash"`
+	List      string `json:"list"`
+	Context   string `json:"context"`
+	Delta     bool   `json:"delta"`
+}
+
+// PutLists carries a batch of the shopping lists of a key range on /sendKeys.
+type PutLists struct {
+	Header
+	Lists []*PutList `json:"lists"`
+}
+
+// SyncLists carries shopping lists on /syncShoppingList.
+type SyncLists struct {
+	Header
+	Lists []SyncList `json:"lists"`
+}
+
+// Encode stamps msg with the protocol version and encodes it as JSON.
+func Encode(msg Message) ([]byte, error) {
+	msg.header().Version = Version
+	return json.Marshal(msg)
+}
+
+// Decode decodes a JSON message and checks its protocol version.
+func Decode(data []byte, msg Message) error {
+	err := json.Unmarshal(data, msg)
+	if err != nil {
+		return err
+	}
+	if msg.header().Version != Version {
+		return fmt.Errorf("%w %d", ErrVersion, msg.header().Version)
+	}
+	return nil
+}
+
+// ReadRequest decodes the body of request into msg.
+func ReadRequest(request *http.Request, msg Message) error {
+	defer request.Body.Close()
+	data, err := io.ReadAll(request.Body)
+	if err != nil {
+		return err
+	}
+	return Decode(data, msg)
+}
+
+// Write sends msg as a JSON answer with the given status.
+func Write(writer http.ResponseWriter, status int, msg Message) error {
+	data, err := Encode(msg)
+	if err != nil {
+		return err
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_, err = writer.Write(data)
+	return err
+}
+
+// Error answers with an ErrorResponse, in the same way as http.Error.
+func Error(writer http.ResponseWriter, text string, status int) {
+	err := Write(writer, status, &ErrorResponse{Error: text})
+	if err != nil {
+		fmt.Println("Error writing response:", err)
+	}
+}
+
+// Post sends request to url and decodes the answer into response when it is 200 OK and response is not nil.
+// The returned error is only set when the exchange itself failed, the caller checks the status.
+func Post(url string, request Message, response Message) (int, error) {
+	return PostWith(http.DefaultClient, url, request, response)
+}
+
+// PostWithTimeout is Post giving up when the answer takes longer than timeout.
+func PostWithTimeout(url string, request Message, response Message, timeout time.Duration) (int, error) {
+	return PostWith(&http.Client{Timeout: timeout}, url, request, response)
+}
+
+// PostWith is Post sending the request with client.
+func PostWith(client *http.Client, url string, request Message, response Message) (int, error) {
+	data, err := Encode(request)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	return readResponse(resp, response)
+}
+
+// Get fetches url and decodes the answer into response when it is 200 OK.
+func Get(url string, response Message) (int, error) {
+	return GetWith(http.DefaultClient, url, response)
+}
+
+// GetWith is Get sending the request with client.
+func GetWith(client *http.Client, url string, response Message) (int, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	return readResponse(resp, response)
+}
+
+func readResponse(resp *http.Response, response Message) (int, error) {
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK || response == nil {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, Decode(data, response)
+}
+
+// UnexpectedStatus describes an answer of peer that is not 200 OK.
+func UnexpectedStatus(peer string, status int) error {
+	return fmt.Errorf("%s responded with %d %s", peer, status, http.StatusText(status))
+}
+
+// StatusError is the failure of a request, answered with Status whatever the transport.
+type StatusError struct {
+	Status int
+	Text   string
+}
+
+func (e *StatusError) Error() string {
+	return e.Text
+}
+
+func Errorf(status int, format string, args ...any) error {
+	return &StatusError{Status: status, Text: fmt.Sprintf(format, args...)}
+}
+
+// Status returns the HTTP status err is answered with, 500 when it carries none.
+func Status(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	var statusError *StatusError
+	if errors.As(err, &statusError) {
+		return statusError.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// Fail answers a request with err.
+func Fail(writer http.ResponseWriter, err error) {
+	Error(writer, err.Error(), Status(err))
+}
@@ -0,0 +1,213 @@
+package rpc
+
+import (
+	"CloudShoppingList/message"
+	"encoding/base64"
+)
+
+// Lists and causal contexts travel as GOB64 strings over HTTP and as raw GOB bytes here.
+
+func fromGOB64(encoded string) []byte {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func toGOB64(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func putListToProto(put *message.PutList) *PutListRequest {
+	return &PutListRequest{Email: put.Email, List: fromGOB64(put.List), Delta: put.Delta, Hint: put.Hint, Epoch: put.Epoch}
+}
+
+func putListFromProto(put *PutListRequest) *message.PutList {
+	return &message.PutList{Email: put.Email, List: toGOB64(put.List), Delta: put.Delta, Hint: put.Hint, Epoch: put.Epoch}
+}
+
+func shoppingListToProto(list *message.ShoppingList) *ShoppingList {
+	return &ShoppingList{Email: list.Email, List: fromGOB64(list.List)}
+}
+
+func shoppingListFromProto(list *ShoppingList) *message.ShoppingList {
+	return &message.ShoppingList{Email: list.Email, List: toGOB64(list.List)}
+}
+
+func membershipToProto(membership *message.Membership) *Membership {
+	converted := &Membership{Epoch: membership.Epoch, ReplicationFactor: int32(membership.ReplicationFactor), Hash: membership.Hash, Partitioner: membership.Partitioner}
+	for _, member := range membership.Members {
+		converted.Members = append(converted.Members, &Member{
+			Id:           member.ID,
+			Address:      member.Address,
+			VirtualNodes: int32(member.VirtualNodes),
+			Weight:       int32(member.Weight),
+			Zone:         member.Zone,
+			Status:       member.Status,
+			Version:      member.Version,
+			Heartbeat:    member.Heartbeat,
+		})
+	}
+	return converted
+}
+
+func membershipFromProto(membership *Membership) *message.Membership {
+	converted := &message.Membership{Epoch: membership.Epoch, ReplicationFactor: int(membership.ReplicationFactor), Hash: membership.Hash, Partitioner: membership.Partitioner}
+	for _, member := range membership.Members {
+		converted.Members = append(converted.Members, message.Member{
+			ID:           member.Id,
+			Address:      member.Address,
+			VirtualNodes: int(member.VirtualNodes),
+			Weight:       int(member.Weight),
+			Zone:         member.Zone,
+			Status:       member.Status,
+			Version:      member.Version,
+			Heartbeat:    member.Heartbeat,
+		})
+	}
+	return converted
+}
+
+func keyRangeToProto(keyRange message.KeyRange) *KeyRange {
+	return &KeyRange{Start: keyRange.Start, End: keyRange.End}
+}
+
+func keyRangeFromProto(keyRange *KeyRange) message.KeyRange {
+	return message.KeyRange{Start: keyRange.GetStart(), End: keyRange.GetEnd()}
+}
+
+func keyTransferToProto(transfer *message.KeyTransfer) *KeyTransfer {
+	return &KeyTransfer{To: transfer.To, Range: keyRangeToProto(transfer.Range)}
+}
+
+func keyTransferFromProto(transfer *KeyTransfer) *message.KeyTransfer {
+	return &message.KeyTransfer{To: transfer.To, Range: keyRangeFromProto(transfer.Range)}
+}
+
+func fetchKeysToProto(request *message.FetchKeys) *FetchKeysRequest {
+	return &FetchKeysRequest{Range: keyRangeToProto(request.Range), After: request.After, Limit: int32(request.Limit)}
+}
+
+func fetchKeysFromProto(request *FetchKeysRequest) *message.FetchKeys {
+	return &message.FetchKeys{Range: keyRangeFromProto(request.Range), After: request.After, Limit: int(request.Limit)}
+}
+
+func keyBatchToProto(batch *message.KeyBatch) *KeyBatch {
+	converted := &KeyBatch{Done: batch.Done}
+	for _, list := range batch.Lists {
+		converted.Lists = append(converted.Lists, &StoredList{Email: list.Email, EmailHash: list.EmailHash, List: fromGOB64(list.List)})
+	}
+	return converted
+}
+
+func keyBatchFromProto(batch *KeyBatch) *message.KeyBatch {
+	converted := &message.KeyBatch{Done: batch.Done}
+	for _, list := range batch.Lists {
+		converted.Lists = append(converted.Lists, message.StoredList{Email: list.Email, EmailHash: list.EmailHash, List: toGOB64(list.List)})
+	}
+	return converted
+}
+
+func indexesToProto(indexes []int) []int32 {
+	var converted []int32
+	for _, index := range indexes {
+		converted = append(converted, int32(index))
+	}
+	return converted
+}
+
+func indexesFromProto(indexes []int32) []int {
+	var converted []int
+	for _, index := range indexes {
+		converted = append(converted, int(index))
+	}
+	return converted
+}
+
+func syncTreeToProto(request *message.SyncTree) *SyncTreeRequest {
+	converted := &SyncTreeRequest{Range: keyRangeToProto(request.Range), Epoch: request.Epoch}
+	for _, node := range request.Nodes {
+		converted.Nodes = append(converted.Nodes, &TreeNode{Index: int32(node.Index), Hash: node.Hash})
+	}
+	return converted
+}
+
+func syncTreeFromProto(request *SyncTreeRequest) *message.SyncTree {
+	converted := &message.SyncTree{Range: keyRangeFromProto(request.Range), Epoch: request.Epoch}
+	for _, node := range request.Nodes {
+		converted.Nodes = append(converted.Nodes, message.TreeNode{Index: int(node.Index), Hash: node.Hash})
+	}
+	return converted
+}
+
+func syncKeysToProto(request *message.SyncKeys) *SyncKeysRequest {
+	converted := &SyncKeysRequest{Range: keyRangeToProto(request.Range), Leaves: indexesToProto(request.Leaves), Epoch: request.Epoch}
+	for _, digest := range request.Digests {
+		converted.Digests = append(converted.Digests, &ListDigest{EmailHash: digest.EmailHash, Digest: digest.Digest, Context: fromGOB64(digest.Context)})
+	}
+	return converted
+}
+
+func syncKeysFromProto(request *SyncKeysRequest) *message.SyncKeys {
+	converted := &message.SyncKeys{Range: keyRangeFromProto(request.Range), Leaves: indexesFromProto(request.Leaves), Epoch: request.Epoch}
+	for _, digest := range request.Digests {
+		converted.Digests = append(converted.Digests, message.ListDigest{EmailHash: digest.EmailHash, Digest: digest.Digest, Context: toGOB64(digest.Context)})
+	}
+	return converted
+}
+
+func syncListsToProto(lists []message.SyncList) []*SyncList {
+	var converted []*SyncList
+	for _, list := range lists {
+		converted = append(converted, &SyncList{
+			Email:     list.Email,
+			EmailHash: list.EmailHash,
+			List:      fromGOB64(list.List),
+			Context:   fromGOB64(list.Context),
+			Delta:     list.Delta,
+		})
+	}
+	return converted
+}
+
+func syncListsFromProto(lists []*SyncList) []message.SyncList {
+	var converted []message.SyncList
+	for _, list := range lists {
+		converted = append(converted, message.SyncList{
+			Email:     list.Email,
+			EmailHash: list.EmailHash,
+			List:      toGOB64(list.List),
+			Context:   toGOB64(list.Context),
+			Delta:     list.Delta,
+		})
+	}
+	return converted
+}
+
+func syncKeysResponseToProto(response *message.SyncKeysResponse) *SyncKeysResponse {
+	converted := &SyncKeysResponse{Lists: syncListsToProto(response.Lists)}
+	for _, wanted := range response.Wanted {
+		converted.Wanted = append(converted.Wanted, &WantedList{EmailHash: wanted.EmailHash, Context: fromGOB64(wanted.Context)})
+	}
+	return converted
+}
+
+func syncKeysResponseFromProto(response *SyncKeysResponse) *message.SyncKeysResponse {
+	converted := &message.SyncKeysResponse{Lists: syncListsFromProto(response.Lists)}
+	for _, wanted := range response.Wanted {
+		converted.Wanted = append(converted.Wanted, message.WantedList{EmailHash: wanted.EmailHash, Context: toGOB64(wanted.Context)})
+	}
+	return converted
+}
+
+func nodeWeightToProto(request *message.NodeWeight) *NodeWeightRequest {
+	return &NodeWeightRequest{Id: request.ID, Weight: int32(request.Weight), VirtualNodes: int32(request.VirtualNodes)}
+}
+
+func nodeWeightFromProto(request *NodeWeightRequest) *message.NodeWeight {
+	return &message.NodeWeight{ID: request.Id, Weight: int(request.Weight), VirtualNodes: int(request.VirtualNodes)}
+}
@@ -0,0 +1,179 @@
+package rpc
+
+import (
+	"CloudShoppingList/message"
+	"context"
+	"io"
+	"net"
+
+	"google.golang.org/grpc"
+)
+
+// StorageHandler is the request handling a storage server shares between its HTTP endpoints
+// and its gRPC service. Errors carry the HTTP status they are answered with, see message.Errorf.
+type StorageHandler interface {
+	PutList(put *message.PutList) error
+	// GetList reads the list of email for a load balancer routing with the ring at epoch.
+	GetList(email string, epoch uint64) (*message.ShoppingList, error)
+	// Gossip merges the membership of a peer and answers with the one of this server.
+	Gossip(membership *message.Membership) (*message.Membership, error)
+	Leave(leave *message.DisconnectNode) error
+	SetWeight(weight *message.NodeWeight) error
+	TransferKeys(transfer *message.KeyTransfer) error
+	FetchKeys(request *message.FetchKeys) (*message.KeyBatch, error)
+	SyncTree(request *message.SyncTree) (*message.SyncTreeResponse, error)
+	SyncKeys(request *message.SyncKeys) (*message.SyncKeysResponse, error)
+	SyncLists(lists *message.SyncLists) error
+}
+
+// LoadBalancerHandler is the request handling the load balancer shares between its HTTP
+// endpoints and its gRPC service.
+type LoadBalancerHandler interface {
+	ConnectNode(connect *message.ConnectNode) (*message.Membership, error)
+	DisconnectNode(disconnect *message.DisconnectNode) error
+	SetWeight(weight *message.NodeWeight) error
+	PutList(put *message.PutList) error
+	GetList(email string) (*message.ShoppingList, error)
+}
+
+type storageService struct {
+	UnimplementedStorageServer
+	handler StorageHandler
+}
+
+func (service *storageService) PutList(_ context.Context, request *PutListRequest) (*Ack, error) {
+	return &Ack{}, ToStatus(service.handler.PutList(putListFromProto(request)))
+}
+
+func (service *storageService) GetList(_ context.Context, request *GetListRequest) (*ShoppingList, error) {
+	list, err := service.handler.GetList(request.Email, request.Epoch)
+	if err != nil {
+		return nil, ToStatus(err)
+	}
+	return shoppingListToProto(list), nil
+}
+
+func (service *storageService) Gossip(_ context.Context, request *Membership) (*Membership, error) {
+	membership, err := service.handler.Gossip(membershipFromProto(request))
+	if err != nil {
+		return nil, ToStatus(err)
+	}
+	return membershipToProto(membership), nil
+}
+
+func (service *storageService) Leave(_ context.Context, request *DisconnectNodeRequest) (*Ack, error) {
+	return &Ack{}, ToStatus(service.handler.Leave(&message.DisconnectNode{ID: request.Id}))
+}
+
+func (service *storageService) SetWeight(_ context.Context, request *NodeWeightRequest) (*Ack, error) {
+	return &Ack{}, ToStatus(service.handler.SetWeight(nodeWeightFromProto(request)))
+}
+
+func (service *storageService) TransferKeys(_ context.Context, transfer *KeyTransfer) (*Ack, error) {
+	return &Ack{}, ToStatus(service.handler.TransferKeys(keyTransferFromProto(transfer)))
+}
+
+// SendKeys merges the streamed lists as they arrive.
+func (service *storageService) SendKeys(stream Storage_SendKeysServer) error {
+	for {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&Ack{})
+		}
+		if err != nil {
+			return err
+		}
+		err = service.handler.PutList(putListFromProto(request))
+		if err != nil {
+			return ToStatus(err)
+		}
+	}
+}
+
+func (service *storageService) FetchKeys(_ context.Context, request *FetchKeysRequest) (*KeyBatch, error) {
+	batch, err := service.handler.FetchKeys(fetchKeysFromProto(request))
+	if err != nil {
+		return nil, ToStatus(err)
+	}
+	return keyBatchToProto(batch), nil
+}
+
+func (service *storageService) SyncTree(_ context.Context, request *SyncTreeRequest) (*SyncTreeResponse, error) {
+	response, err := service.handler.SyncTree(syncTreeFromProto(request))
+	if err != nil {
+		return nil, ToStatus(err)
+	}
+	return &SyncTreeResponse{Differing: indexesToProto(response.Differing)}, nil
+}
+
+func (service *storageService) SyncKeys(_ context.Context, request *SyncKeysRequest) (*SyncKeysResponse, error) {
+	response, err := service.handler.SyncKeys(syncKeysFromProto(request))
+	if err != nil {
+		return nil, ToStatus(err)
+	}
+	return syncKeysResponseToProto(response), nil
+}
+
+func (service *storageService) SyncLists(_ context.Context, request *SyncListsRequest) (*Ack, error) {
+	return &Ack{}, ToStatus(service.handler.SyncLists(&message.SyncLists{Lists: syncListsFromProto(request.Lists)}))
+}
+
+func (service *storageService) Health(context.Context, *HealthRequest) (*Ack, error) {
+	return &Ack{}, nil
+}
+
+type loadBalancerService struct {
+	UnimplementedLoadBalancerServer
+	handler LoadBalancerHandler
+}
+
+func (service *loadBalancerService) ConnectNode(_ context.Context, request *ConnectNodeRequest) (*Membership, error) {
+	membership, err := service.handler.ConnectNode(&message.ConnectNode{ID: request.Id, Address: request.Address, Weight: int(request.Weight), Zone: request.Zone, Hash: request.Hash, Partitioner: request.Partitioner})
+	if err != nil {
+		return nil, ToStatus(err)
+	}
+	return membershipToProto(membership), nil
+}
+
+func (service *loadBalancerService) DisconnectNode(_ context.Context, request *DisconnectNodeRequest) (*Ack, error) {
+	return &Ack{}, ToStatus(service.handler.DisconnectNode(&message.DisconnectNode{ID: request.Id}))
+}
+
+func (service *loadBalancerService) SetWeight(_ context.Context, request *NodeWeightRequest) (*Ack, error) {
+	return &Ack{}, ToStatus(service.handler.SetWeight(nodeWeightFromProto(request)))
+}
+
+func (service *loadBalancerService) PutList(_ context.Context, request *PutListRequest) (*Ack, error) {
+	return &Ack{}, ToStatus(service.handler.PutList(putListFromProto(request)))
+}
+
+func (service *loadBalancerService) GetList(_ context.Context, request *GetListRequest) (*ShoppingList, error) {
+	list, err := service.handler.GetList(request.Email)
+	if err != nil {
+		return nil, ToStatus(err)
+	}
+	return shoppingListToProto(list), nil
+}
+
+// NewStorageServer returns the gRPC server of a storage server.
+func NewStorageServer(handler StorageHandler) *grpc.Server {
+	server := grpc.NewServer()
+	RegisterStorageServer(server, &storageService{handler: handler})
+	return server
+}
+
+// NewLoadBalancerServer returns the gRPC server of a load balancer.
+func NewLoadBalancerServer(handler LoadBalancerHandler) *grpc.Server {
+	server := grpc.NewServer()
+	RegisterLoadBalancerServer(server, &loadBalancerService{handler: handler})
+	return server
+}
+
+// Listen listens on the gRPC port of the node listening for HTTP on address.
+func Listen(address string) (net.Listener, error) {
+	target, err := AddressOf(address)
+	if err != nil {
+		return nil, err
+	}
+	return net.Listen("tcp", target)
+}
@@ -0,0 +1,803 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: shopping_list.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Storage_PutList_FullMethodName      = "/shoppinglist.Storage/PutList"
+	Storage_GetList_FullMethodName      = "/shoppinglist.Storage/GetList"
+	Storage_Gossip_FullMethodName       = "/shoppinglist.Storage/Gossip"
+	Storage_Leave_FullMethodName        = "/shoppinglist.Storage/Leave"
+	Storage_SetWeight_FullMethodName    = "/shoppinglist.Storage/SetWeight"
+	Storage_TransferKeys_FullMethodName = "/shoppinglist.Storage/TransferKeys"
+	Storage_SendKeys_FullMethodName     = "/shoppinglist.Storage/SendKeys"
+	Storage_FetchKeys_FullMethodName    = "/shoppinglist.Storage/FetchKeys"
+	Storage_SyncTree_FullMethodName     = "/shoppinglist.Storage/SyncTree"
+	Storage_SyncKeys_FullMethodName     = "/shoppinglist.Storage/SyncKeys"
+	Storage_SyncLists_FullMethodName    = "/shoppinglist.Storage/SyncLists"
+	Storage_Health_FullMethodName       = "/shoppinglist.Storage/Health"
+)
+
+// StorageClient is the client API for Storage service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type StorageClient interface {
+	// PutList merges a shopping list, or a delta of it, into the stored one.
+	PutList(ctx context.Context, in *PutListRequest, opts ...grpc.CallOption) (*Ack, error)
+	GetList(ctx context.Context, in *GetListRequest, opts ...grpc.CallOption) (*ShoppingList, error)
+	// Gossip merges the membership of the sender and answers with the one of the server.
+	Gossip(ctx context.Context, in *Membership, opts ...grpc.CallOption) (*Membership, error)
+	// Leave makes the server hand its keys over to their new owners and leave the ring.
+	Leave(ctx context.Context, in *DisconnectNodeRequest, opts ...grpc.CallOption) (*Ack, error)
+	// SetWeight makes the server take the virtual nodes of a new weight, moving the ranges that change hands.
+	SetWeight(ctx context.Context, in *NodeWeightRequest, opts ...grpc.CallOption) (*Ack, error)
+	// TransferKeys makes the server stream the lists of a range to another server.
+	TransferKeys(ctx context.Context, in *KeyTransfer, opts ...grpc.CallOption) (*Ack, error)
+	// SendKeys receives the lists of a range in bulk.
+	SendKeys(ctx context.Context, opts ...grpc.CallOption) (Storage_SendKeysClient, error)
+	// FetchKeys answers with the next batch of lists of a range.
+	FetchKeys(ctx context.Context, in *FetchKeysRequest, opts ...grpc.CallOption) (*KeyBatch, error)
+	SyncTree(ctx context.Context, in *SyncTreeRequest, opts ...grpc.CallOption) (*SyncTreeResponse, error)
+	SyncKeys(ctx context.Context, in *SyncKeysRequest, opts ...grpc.CallOption) (*SyncKeysResponse, error)
+	SyncLists(ctx context.Context, in *SyncListsRequest, opts ...grpc.CallOption) (*Ack, error)
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*Ack, error)
+}
+
+type storageClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStorageClient(cc grpc.ClientConnInterface) StorageClient {
+	return &storageClient{cc}
+}
+
+func (c *storageClient) PutList(ctx context.Context, in *PutListRequest, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, Storage_PutList_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) GetList(ctx context.Context, in *GetListRequest, opts ...grpc.CallOption) (*ShoppingList, error) {
+	out := new(ShoppingList)
+	err := c.cc.Invoke(ctx, Storage_GetList_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Gossip(ctx context.Context, in *Membership, opts ...grpc.CallOption) (*Membership, error) {
+	out := new(Membership)
+	err := c.cc.Invoke(ctx, Storage_Gossip_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Leave(ctx context.Context, in *DisconnectNodeRequest, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, Storage_Leave_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) SetWeight(ctx context.Context, in *NodeWeightRequest, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, Storage_SetWeight_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) TransferKeys(ctx context.Context, in *KeyTransfer, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, Storage_TransferKeys_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) SendKeys(ctx context.Context, opts ...grpc.CallOption) (Storage_SendKeysClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Storage_ServiceDesc.Streams[0], Storage_SendKeys_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storageSendKeysClient{stream}
+	return x, nil
+}
+
+type Storage_SendKeysClient interface {
+	Send(*PutListRequest) error
+	CloseAndRecv() (*Ack, error)
+	grpc.ClientStream
+}
+
+type storageSendKeysClient struct {
+	grpc.ClientStream
+}
+
+func (x *storageSendKeysClient) Send(m *PutListRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *storageSendKeysClient) CloseAndRecv() (*Ack, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(Ack)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *storageClient) FetchKeys(ctx context.Context, in *FetchKeysRequest, opts ...grpc.CallOption) (*KeyBatch, error) {
+	out := new(KeyBatch)
+	err := c.cc.Invoke(ctx, Storage_FetchKeys_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) SyncTree(ctx context.Context, in *SyncTreeRequest, opts ...grpc.CallOption) (*SyncTreeResponse, error) {
+	out := new(SyncTreeResponse)
+	err := c.cc.Invoke(ctx, Storage_SyncTree_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) SyncKeys(ctx context.Context, in *SyncKeysRequest, opts ...grpc.CallOption) (*SyncKeysResponse, error) {
+	out := new(SyncKeysResponse)
+	err := c.cc.Invoke(ctx, Storage_SyncKeys_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) SyncLists(ctx context.Context, in *SyncListsRequest, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, Storage_SyncLists_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, Storage_Health_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StorageServer is the server API for Storage service.
+// All implementations must embed UnimplementedStorageServer
+// for forward compatibility
+type StorageServer interface {
+	// PutList merges a shopping list, or a delta of it, into the stored one.
+	PutList(context.Context, *PutListRequest) (*Ack, error)
+	GetList(context.Context, *GetListRequest) (*ShoppingList, error)
+	// Gossip merges the membership of the sender and answers with the one of the server.
+	Gossip(context.Context, *Membership) (*Membership, error)
+	// Leave makes the server hand its keys over to their new owners and leave the ring.
+	Leave(context.Context, *DisconnectNodeRequest) (*Ack, error)
+	// SetWeight makes the server take the virtual nodes of a new weight, moving the ranges that change hands.
+	SetWeight(context.Context, *NodeWeightRequest) (*Ack, error)
+	// TransferKeys makes the server stream the lists of a range to another server.
+	TransferKeys(context.Context, *KeyTransfer) (*Ack, error)
+	// SendKeys receives the lists of a range in bulk.
+	SendKeys(Storage_SendKeysServer) error
+	// FetchKeys answers with the next batch of lists of a range.
+	FetchKeys(context.Context, *FetchKeysRequest) (*KeyBatch, error)
+	SyncTree(context.Context, *SyncTreeRequest) (*SyncTreeResponse, error)
+	SyncKeys(context.Context, *SyncKeysRequest) (*SyncKeysResponse, error)
+	SyncLists(context.Context, *SyncListsRequest) (*Ack, error)
+	Health(context.Context, *HealthRequest) (*Ack, error)
+	mustEmbedUnimplementedStorageServer()
+}
+
+// UnimplementedStorageServer must be embedded to have forward compatible implementations.
+type UnimplementedStorageServer struct {
+}
+
+func (UnimplementedStorageServer) PutList(context.Context, *PutListRequest) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutList not implemented")
+}
+func (UnimplementedStorageServer) GetList(context.Context, *GetListRequest) (*ShoppingList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetList not implemented")
+}
+func (UnimplementedStorageServer) Gossip(context.Context, *Membership) (*Membership, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Gossip not implemented")
+}
+func (UnimplementedStorageServer) Leave(context.Context, *DisconnectNodeRequest) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Leave not implemented")
+}
+func (UnimplementedStorageServer) SetWeight(context.Context, *NodeWeightRequest) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWeight not implemented")
+}
+func (UnimplementedStorageServer) TransferKeys(context.Context, *KeyTransfer) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferKeys not implemented")
+}
+func (UnimplementedStorageServer) SendKeys(Storage_SendKeysServer) error {
+	return status.Errorf(codes.Unimplemented, "method SendKeys not implemented")
+}
+func (UnimplementedStorageServer) FetchKeys(context.Context, *FetchKeysRequest) (*KeyBatch, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchKeys not implemented")
+}
+func (UnimplementedStorageServer) SyncTree(context.Context, *SyncTreeRequest) (*SyncTreeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncTree not implemented")
+}
+func (UnimplementedStorageServer) SyncKeys(context.Context, *SyncKeysRequest) (*SyncKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncKeys not implemented")
+}
+func (UnimplementedStorageServer) SyncLists(context.Context, *SyncListsRequest) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncLists not implemented")
+}
+func (UnimplementedStorageServer) Health(context.Context, *HealthRequest) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedStorageServer) mustEmbedUnimplementedStorageServer() {}
+
+// UnsafeStorageServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StorageServer will
+// result in compilation errors.
+type UnsafeStorageServer interface {
+	mustEmbedUnimplementedStorageServer()
+}
+
+func RegisterStorageServer(s grpc.ServiceRegistrar, srv StorageServer) {
+	s.RegisterService(&Storage_ServiceDesc, srv)
+}
+
+func _Storage_PutList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).PutList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storage_PutList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).PutList(ctx, req.(*PutListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_GetList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).GetList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storage_GetList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).GetList(ctx, req.(*GetListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Gossip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Membership)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Gossip(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storage_Gossip_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Gossip(ctx, req.(*Membership))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Leave_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisconnectNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Leave(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storage_Leave_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Leave(ctx, req.(*DisconnectNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_SetWeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeWeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).SetWeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storage_SetWeight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).SetWeight(ctx, req.(*NodeWeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_TransferKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyTransfer)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).TransferKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storage_TransferKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).TransferKeys(ctx, req.(*KeyTransfer))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_SendKeys_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(StorageServer).SendKeys(&storageSendKeysServer{stream})
+}
+
+type Storage_SendKeysServer interface {
+	SendAndClose(*Ack) error
+	Recv() (*PutListRequest, error)
+	grpc.ServerStream
+}
+
+type storageSendKeysServer struct {
+	grpc.ServerStream
+}
+
+func (x *storageSendKeysServer) SendAndClose(m *Ack) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *storageSendKeysServer) Recv() (*PutListRequest, error) {
+	m := new(PutListRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Storage_FetchKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).FetchKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storage_FetchKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).FetchKeys(ctx, req.(*FetchKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_SyncTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncTreeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).SyncTree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storage_SyncTree_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).SyncTree(ctx, req.(*SyncTreeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_SyncKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).SyncKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storage_SyncKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).SyncKeys(ctx, req.(*SyncKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_SyncLists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncListsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).SyncLists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storage_SyncLists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).SyncLists(ctx, req.(*SyncListsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Storage_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Storage_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Storage_ServiceDesc is the grpc.ServiceDesc for Storage service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Storage_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "shoppinglist.Storage",
+	HandlerType: (*StorageServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PutList",
+			Handler:    _Storage_PutList_Handler,
+		},
+		{
+			MethodName: "GetList",
+			Handler:    _Storage_GetList_Handler,
+		},
+		{
+			MethodName: "Gossip",
+			Handler:    _Storage_Gossip_Handler,
+		},
+		{
+			MethodName: "Leave",
+			Handler:    _Storage_Leave_Handler,
+		},
+		{
+			MethodName: "SetWeight",
+			Handler:    _Storage_SetWeight_Handler,
+		},
+		{
+			MethodName: "TransferKeys",
+			Handler:    _Storage_TransferKeys_Handler,
+		},
+		{
+			MethodName: "FetchKeys",
+			Handler:    _Storage_FetchKeys_Handler,
+		},
+		{
+			MethodName: "SyncTree",
+			Handler:    _Storage_SyncTree_Handler,
+		},
+		{
+			MethodName: "SyncKeys",
+			Handler:    _Storage_SyncKeys_Handler,
+		},
+		{
+			MethodName: "SyncLists",
+			Handler:    _Storage_SyncLists_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _Storage_Health_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SendKeys",
+			Handler:       _Storage_SendKeys_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "shopping_list.proto",
+}
+
+const (
+	LoadBalancer_ConnectNode_FullMethodName    = "/shoppinglist.LoadBalancer/ConnectNode"
+	LoadBalancer_DisconnectNode_FullMethodName = "/shoppinglist.LoadBalancer/DisconnectNode"
+	LoadBalancer_SetWeight_FullMethodName      = "/shoppinglist.LoadBalancer/SetWeight"
+	LoadBalancer_PutList_FullMethodName        = "/shoppinglist.LoadBalancer/PutList"
+	LoadBalancer_GetList_FullMethodName        = "/shoppinglist.LoadBalancer/GetList"
+)
+
+// LoadBalancerClient is the client API for LoadBalancer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LoadBalancerClient interface {
+	ConnectNode(ctx context.Context, in *ConnectNodeRequest, opts ...grpc.CallOption) (*Membership, error)
+	DisconnectNode(ctx context.Context, in *DisconnectNodeRequest, opts ...grpc.CallOption) (*Ack, error)
+	SetWeight(ctx context.Context, in *NodeWeightRequest, opts ...grpc.CallOption) (*Ack, error)
+	PutList(ctx context.Context, in *PutListRequest, opts ...grpc.CallOption) (*Ack, error)
+	GetList(ctx context.Context, in *GetListRequest, opts ...grpc.CallOption) (*ShoppingList, error)
+}
+
+type loadBalancerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLoadBalancerClient(cc grpc.ClientConnInterface) LoadBalancerClient {
+	return &loadBalancerClient{cc}
+}
+
+func (c *loadBalancerClient) ConnectNode(ctx context.Context, in *ConnectNodeRequest, opts ...grpc.CallOption) (*Membership, error) {
+	out := new(Membership)
+	err := c.cc.Invoke(ctx, LoadBalancer_ConnectNode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loadBalancerClient) DisconnectNode(ctx context.Context, in *DisconnectNodeRequest, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, LoadBalancer_DisconnectNode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loadBalancerClient) SetWeight(ctx context.Context, in *NodeWeightRequest, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, LoadBalancer_SetWeight_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loadBalancerClient) PutList(ctx context.Context, in *PutListRequest, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, LoadBalancer_PutList_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loadBalancerClient) GetList(ctx context.Context, in *GetListRequest, opts ...grpc.CallOption) (*ShoppingList, error) {
+	out := new(ShoppingList)
+	err := c.cc.Invoke(ctx, LoadBalancer_GetList_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LoadBalancerServer is the server API for LoadBalancer service.
+// All implementations must embed UnimplementedLoadBalancerServer
+// for forward compatibility
+type LoadBalancerServer interface {
+	ConnectNode(context.Context, *ConnectNodeRequest) (*Membership, error)
+	DisconnectNode(context.Context, *DisconnectNodeRequest) (*Ack, error)
+	SetWeight(context.Context, *NodeWeightRequest) (*Ack, error)
+	PutList(context.Context, *PutListRequest) (*Ack, error)
+	GetList(context.Context, *GetListRequest) (*ShoppingList, error)
+	mustEmbedUnimplementedLoadBalancerServer()
+}
+
+// UnimplementedLoadBalancerServer must be embedded to have forward compatible implementations.
+type UnimplementedLoadBalancerServer struct {
+}
+
+func (UnimplementedLoadBalancerServer) ConnectNode(context.Context, *ConnectNodeRequest) (*Membership, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConnectNode not implemented")
+}
+func (UnimplementedLoadBalancerServer) DisconnectNode(context.Context, *DisconnectNodeRequest) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisconnectNode not implemented")
+}
+func (UnimplementedLoadBalancerServer) SetWeight(context.Context, *NodeWeightRequest) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetWeight not implemented")
+}
+func (UnimplementedLoadBalancerServer) PutList(context.Context, *PutListRequest) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutList not implemented")
+}
+func (UnimplementedLoadBalancerServer) GetList(context.Context, *GetListRequest) (*ShoppingList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetList not implemented")
+}
+func (UnimplementedLoadBalancerServer) mustEmbedUnimplementedLoadBalancerServer() {}
+
+// UnsafeLoadBalancerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LoadBalancerServer will
+// result in compilation errors.
+type UnsafeLoadBalancerServer interface {
+	mustEmbedUnimplementedLoadBalancerServer()
+}
+
+func RegisterLoadBalancerServer(s grpc.ServiceRegistrar, srv LoadBalancerServer) {
+	s.RegisterService(&LoadBalancer_ServiceDesc, srv)
+}
+
+func _LoadBalancer_ConnectNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoadBalancerServer).ConnectNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LoadBalancer_ConnectNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoadBalancerServer).ConnectNode(ctx, req.(*ConnectNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LoadBalancer_DisconnectNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisconnectNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoadBalancerServer).DisconnectNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LoadBalancer_DisconnectNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoadBalancerServer).DisconnectNode(ctx, req.(*DisconnectNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LoadBalancer_SetWeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeWeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoadBalancerServer).SetWeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LoadBalancer_SetWeight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoadBalancerServer).SetWeight(ctx, req.(*NodeWeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LoadBalancer_PutList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoadBalancerServer).PutList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LoadBalancer_PutList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoadBalancerServer).PutList(ctx, req.(*PutListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LoadBalancer_GetList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoadBalancerServer).GetList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LoadBalancer_GetList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoadBalancerServer).GetList(ctx, req.(*GetListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LoadBalancer_ServiceDesc is the grpc.ServiceDesc for LoadBalancer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LoadBalancer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "shoppinglist.LoadBalancer",
+	HandlerType: (*LoadBalancerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ConnectNode",
+			Handler:    _LoadBalancer_ConnectNode_Handler,
+		},
+		{
+			MethodName: "DisconnectNode",
+			Handler:    _LoadBalancer_DisconnectNode_Handler,
+		},
+		{
+			MethodName: "SetWeight",
+			Handler:    _LoadBalancer_SetWeight_Handler,
+		},
+		{
+			MethodName: "PutList",
+			Handler:    _LoadBalancer_PutList_Handler,
+		},
+		{
+			MethodName: "GetList",
+			Handler:    _LoadBalancer_GetList_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "shopping_list.proto",
+}
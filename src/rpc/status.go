@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"CloudShoppingList/message"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codes of the HTTP statuses the endpoints answer with
+var statusCodes = map[int]codes.Code{
+	http.StatusOK:                  codes.OK,
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.Aborted,
+	http.StatusPreconditionFailed:  codes.FailedPrecondition,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusBadGateway:          codes.Unknown,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+}
+
+// httpStatuses maps the codes of statusCodes back, for errors carrying no HTTP status
+var httpStatuses = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.NotFound:           http.StatusNotFound,
+	codes.Aborted:            http.StatusConflict,
+	codes.FailedPrecondition: http.StatusPreconditionFailed,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unknown:            http.StatusBadGateway,
+}
+
+// statusReason names the detail carrying the HTTP status of an answer in a gRPC status error.
+const statusReason = "HTTP_STATUS"
+
+// ToStatus turns an error of the shared request handling into a gRPC status error. The HTTP
+// status travels in the details, so the caller gets the same status as over HTTP.
+func ToStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	httpStatus := message.Status(err)
+	code, exists := statusCodes[httpStatus]
+	if !exists {
+		code = codes.Unknown
+	}
+	withStatus, detailErr := status.New(code, err.Error()).WithDetails(&errdetails.ErrorInfo{
+		Reason:   statusReason,
+		Metadata: map[string]string{"status": strconv.Itoa(httpStatus)},
+	})
+	if detailErr != nil {
+		return status.Error(code, err.Error())
+	}
+	return withStatus.Err()
+}
+
+// FromStatus turns the error of a gRPC call into the HTTP status of the answer. Calls that
+// could not reach the peer keep their error, the same way a failed HTTP request does: a peer
+// that answered always sends its HTTP status.
+func FromStatus(err error) (int, error) {
+	if err == nil {
+		return http.StatusOK, nil
+	}
+	grpcStatus, ok := status.FromError(err)
+	if !ok {
+		return 0, err
+	}
+	for _, detail := range grpcStatus.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Reason == statusReason {
+			httpStatus, convErr := strconv.Atoi(info.Metadata["status"])
+			if convErr == nil {
+				return httpStatus, nil
+			}
+		}
+	}
+	switch grpcStatus.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return 0, err
+	}
+	if httpStatus, exists := httpStatuses[grpcStatus.Code()]; exists {
+		return httpStatus, nil
+	}
+	return http.StatusInternalServerError, nil
+}
@@ -0,0 +1,364 @@
+// Package rpc holds the gRPC service of the storage servers and the load balancer, and the
+// transports the nodes talk to each other with.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative shopping_list.proto
+
+import (
+	"CloudShoppingList/message"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// How long a call waits for a failed connection to come back before giving up on the node
+const reconnectTimeout = time.Second
+
+// How long a call on a single list or on a batch of lists waits for its answer
+const callTimeout = 10 * time.Second
+
+// How long a call that moves the keys of whole ranges, leaving or changing weight, waits for its answer
+const transferTimeout = 10 * time.Minute
+
+// PortOffset is added to the HTTP port of a node to get the port of its gRPC service.
+const PortOffset = 1000
+
+// AddressOf returns the gRPC address of the node listening for HTTP on address.
+func AddressOf(address string) (string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(portNumber+PortOffset)), nil
+}
+
+// Transport carries the requests between the load balancer and the storage servers. Nodes are
+// always named by their HTTP address. The calls answer with the HTTP status of the request and
+// only return an error when the peer could not be reached.
+type Transport interface {
+	ConnectNode(loadBalancer string, request *message.ConnectNode) (*message.Membership, int, error)
+	PutList(server string, request *message.PutList) (int, error)
+	GetList(server string, email string, epoch uint64) (*message.ShoppingList, int, error)
+	// Gossip sends membership to server and answers with the membership of server
+	Gossip(server string, membership *message.Membership, timeout time.Duration) (*message.Membership, int, error)
+	Leave(server string, request *message.DisconnectNode) (int, error)
+	SetWeight(server string, request *message.NodeWeight) (int, error)
+	TransferKeys(source string, transfer *message.KeyTransfer) (int, error)
+	// SendKeys sends a batch of the lists of a key range to server in a single call
+	SendKeys(server string, lists []*message.PutList) (int, error)
+	FetchKeys(server string, request *message.FetchKeys) (*message.KeyBatch, int, error)
+	SyncTree(server string, request *message.SyncTree) (*message.SyncTreeResponse, int, error)
+	SyncKeys(server string, request *message.SyncKeys) (*message.SyncKeysResponse, int, error)
+	SyncLists(server string, lists *message.SyncLists) (int, error)
+}
+
+// NewTransport returns the transport called name, "http" or "grpc".
+func NewTransport(name string) (Transport, error) {
+	switch name {
+	case "http":
+		return HTTP{}, nil
+	case "grpc":
+		return NewGRPC(), nil
+	}
+	return nil, fmt.Errorf("unknown transport %q", name)
+}
+
+// HTTP talks to the JSON endpoints of the nodes, giving up on a call after the same timeouts as GRPC.
+type HTTP struct{}
+
+var (
+	callClient     = &http.Client{Timeout: callTimeout}
+	transferClient = &http.Client{Timeout: transferTimeout}
+)
+
+func (HTTP) ConnectNode(loadBalancer string, request *message.ConnectNode) (*message.Membership, int, error) {
+	var response message.Membership
+	status, err := message.PostWith(callClient, "http://"+loadBalancer+"/connect-node", request, &response)
+	return &response, status, err
+}
+
+func (HTTP) PutList(server string, request *message.PutList) (int, error) {
+	return message.PostWith(callClient, "http://"+server+"/putListServer", request, nil)
+}
+
+func (HTTP) GetList(server string, email string, epoch uint64) (*message.ShoppingList, int, error) {
+	var response message.ShoppingList
+	status, err := message.GetWith(callClient, "http://"+server+"/getListServer/"+email+"?epoch="+strconv.FormatUint(epoch, 10), &response)
+	return &response, status, err
+}
+
+func (HTTP) Gossip(server string, membership *message.Membership, timeout time.Duration) (*message.Membership, int, error) {
+	var response message.Membership
+	status, err := message.PostWithTimeout("http://"+server+"/gossip", membership, &response, timeout)
+	return &response, status, err
+}
+
+func (HTTP) Leave(server string, request *message.DisconnectNode) (int, error) {
+	return message.PostWith(transferClient, "http://"+server+"/leave", request, nil)
+}
+
+func (HTTP) SetWeight(server string, request *message.NodeWeight) (int, error) {
+	return message.PostWith(transferClient, "http://"+server+"/weight", request, nil)
+}
+
+func (HTTP) TransferKeys(source string, transfer *message.KeyTransfer) (int, error) {
+	return message.PostWith(transferClient, "http://"+source+"/sendMeKeys", transfer, nil)
+}
+
+func (HTTP) SendKeys(server string, lists []*message.PutList) (int, error) {
+	return message.PostWith(callClient, "http://"+server+"/sendKeys", &message.PutLists{Lists: lists}, nil)
+}
+
+func (HTTP) FetchKeys(server string, request *message.FetchKeys) (*message.KeyBatch, int, error) {
+	var response message.KeyBatch
+	status, err := message.PostWith(callClient, "http://"+server+"/fetchKeys", request, &response)
+	return &response, status, err
+}
+
+func (HTTP) SyncTree(server string, request *message.SyncTree) (*message.SyncTreeResponse, int, error) {
+	var response message.SyncTreeResponse
+	status, err := message.PostWith(callClient, "http://"+server+"/syncTree", request, &response)
+	return &response, status, err
+}
+
+func (HTTP) SyncKeys(server string, request *message.SyncKeys) (*message.SyncKeysResponse, int, error) {
+	var response message.SyncKeysResponse
+	status, err := message.PostWith(callClient, "http://"+server+"/syncKeys", request, &response)
+	return &response, status, err
+}
+
+func (HTTP) SyncLists(server string, lists *message.SyncLists) (int, error) {
+	return message.PostWith(callClient, "http://"+server+"/syncShoppingList", lists, nil)
+}
+
+// GRPC talks to the gRPC services of the nodes, keeping one connection per node.
+type GRPC struct {
+	sync.Mutex
+	connections map[string]*grpc.ClientConn
+}
+
+func NewGRPC() *GRPC {
+	return &GRPC{connections: make(map[string]*grpc.ClientConn)}
+}
+
+func (transport *GRPC) connection(address string) (*grpc.ClientConn, error) {
+	transport.Lock()
+	connection, exists := transport.connections[address]
+	if !exists {
+		target, err := AddressOf(address)
+		if err != nil {
+			transport.Unlock()
+			return nil, err
+		}
+		connection, err = grpc.Dial(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			transport.Unlock()
+			return nil, err
+		}
+		transport.connections[address] = connection
+	}
+	transport.Unlock()
+
+	// a node that was down may be back, try it now instead of after the reconnection backoff
+	if connection.GetState() == connectivity.TransientFailure {
+		connection.ResetConnectBackoff()
+		ctx, cancel := context.WithTimeout(context.Background(), reconnectTimeout)
+		defer cancel()
+		state := connection.GetState()
+		for state != connectivity.Ready && connection.WaitForStateChange(ctx, state) {
+			state = connection.GetState()
+			if state == connectivity.TransientFailure {
+				break
+			}
+		}
+	}
+	return connection, nil
+}
+
+func (transport *GRPC) storage(server string) (StorageClient, error) {
+	connection, err := transport.connection(server)
+	if err != nil {
+		return nil, err
+	}
+	return NewStorageClient(connection), nil
+}
+
+func (transport *GRPC) ConnectNode(loadBalancer string, request *message.ConnectNode) (*message.Membership, int, error) {
+	connection, err := transport.connection(loadBalancer)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	response, err := NewLoadBalancerClient(connection).ConnectNode(ctx, &ConnectNodeRequest{Id: request.ID, Address: request.Address, Weight: int32(request.Weight), Zone: request.Zone, Hash: request.Hash, Partitioner: request.Partitioner})
+	if err != nil {
+		status, err := FromStatus(err)
+		return nil, status, err
+	}
+	return membershipFromProto(response), http.StatusOK, nil
+}
+
+func (transport *GRPC) PutList(server string, request *message.PutList) (int, error) {
+	client, err := transport.storage(server)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	_, err = client.PutList(ctx, putListToProto(request))
+	return FromStatus(err)
+}
+
+func (transport *GRPC) GetList(server string, email string, epoch uint64) (*message.ShoppingList, int, error) {
+	client, err := transport.storage(server)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	response, err := client.GetList(ctx, &GetListRequest{Email: email, Epoch: epoch})
+	if err != nil {
+		status, err := FromStatus(err)
+		return nil, status, err
+	}
+	return shoppingListFromProto(response), http.StatusOK, nil
+}
+
+func (transport *GRPC) Gossip(server string, membership *message.Membership, timeout time.Duration) (*message.Membership, int, error) {
+	client, err := transport.storage(server)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	response, err := client.Gossip(ctx, membershipToProto(membership))
+	if err != nil {
+		status, err := FromStatus(err)
+		return nil, status, err
+	}
+	return membershipFromProto(response), http.StatusOK, nil
+}
+
+func (transport *GRPC) Leave(server string, request *message.DisconnectNode) (int, error) {
+	client, err := transport.storage(server)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), transferTimeout)
+	defer cancel()
+	_, err = client.Leave(ctx, &DisconnectNodeRequest{Id: request.ID})
+	return FromStatus(err)
+}
+
+func (transport *GRPC) SetWeight(server string, request *message.NodeWeight) (int, error) {
+	client, err := transport.storage(server)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), transferTimeout)
+	defer cancel()
+	_, err = client.SetWeight(ctx, nodeWeightToProto(request))
+	return FromStatus(err)
+}
+
+func (transport *GRPC) TransferKeys(source string, transfer *message.KeyTransfer) (int, error) {
+	client, err := transport.storage(source)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), transferTimeout)
+	defer cancel()
+	_, err = client.TransferKeys(ctx, keyTransferToProto(transfer))
+	return FromStatus(err)
+}
+
+// SendKeys streams the lists to server over a single call.
+func (transport *GRPC) SendKeys(server string, lists []*message.PutList) (int, error) {
+	client, err := transport.storage(server)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	stream, err := client.SendKeys(ctx)
+	if err != nil {
+		return FromStatus(err)
+	}
+	for _, list := range lists {
+		err = stream.Send(putListToProto(list))
+		if err != nil {
+			// the reason the stream broke comes with CloseAndRecv
+			break
+		}
+	}
+	_, err = stream.CloseAndRecv()
+	return FromStatus(err)
+}
+
+func (transport *GRPC) FetchKeys(server string, request *message.FetchKeys) (*message.KeyBatch, int, error) {
+	client, err := transport.storage(server)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	response, err := client.FetchKeys(ctx, fetchKeysToProto(request))
+	if err != nil {
+		status, err := FromStatus(err)
+		return nil, status, err
+	}
+	return keyBatchFromProto(response), http.StatusOK, nil
+}
+
+func (transport *GRPC) SyncTree(server string, request *message.SyncTree) (*message.SyncTreeResponse, int, error) {
+	client, err := transport.storage(server)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	response, err := client.SyncTree(ctx, syncTreeToProto(request))
+	if err != nil {
+		status, err := FromStatus(err)
+		return nil, status, err
+	}
+	return &message.SyncTreeResponse{Differing: indexesFromProto(response.Differing)}, http.StatusOK, nil
+}
+
+func (transport *GRPC) SyncKeys(server string, request *message.SyncKeys) (*message.SyncKeysResponse, int, error) {
+	client, err := transport.storage(server)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	response, err := client.SyncKeys(ctx, syncKeysToProto(request))
+	if err != nil {
+		status, err := FromStatus(err)
+		return nil, status, err
+	}
+	return syncKeysResponseFromProto(response), http.StatusOK, nil
+}
+
+func (transport *GRPC) SyncLists(server string, lists *message.SyncLists) (int, error) {
+	client, err := transport.storage(server)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	_, err = client.SyncLists(ctx, &SyncListsRequest{Lists: syncListsToProto(lists.Lists)})
+	return FromStatus(err)
+}
@@ -1,72 +1,108 @@
 package main
 
 import (
+	"CloudShoppingList/causalcontext"
+	"CloudShoppingList/consistent_hashing"
 	"CloudShoppingList/crdt"
+	"CloudShoppingList/membership"
+	"CloudShoppingList/merkle"
+	"CloudShoppingList/message"
+	"CloudShoppingList/rpc"
+	"CloudShoppingList/storage"
 	"bytes"
-	"crypto/sha256"
-	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
 	"fmt"
-	"io"
+	"hash/fnv"
 	"log"
-	"mime/multipart"
+	"math/big"
+	"math/rand"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
-const replicationFactor = 2
-
 type Node struct {
-	id         string
-	hashId     []byte
+	id     string
+	hashId []byte
+	// rangeStart is where the range of the node starts, it owns the range (rangeStart, hashId]
+	rangeStart []byte
+	// frontNodes replicate the range
 	frontNodes []Node
-	backNodes  []Node
 	server     string
 }
 
 type Server struct {
-	port           string
-	name           string
-	loadBalancerIP string
-	db             *sql.DB
-	nodes          []Node
+	port    string
+	name    string
+	address string
+	// capacity of the server relative to the others and the zone it runs in, asked for when connecting
+	weight int
+	zone   string
+	// load balancers the server connects through, tried in turn
+	loadBalancers []string
+	transport     rpc.Transport
+	store         storage.Store
+	// servers of the ring, gossiped with the other servers and saved to membershipPath
+	members        *membership.List
+	membershipPath string
+	// nodes of this server and the epoch of the ring they come from
+	nodes []Node
+	epoch uint64
+	// placement the nodes come from, and the version of every server in it by address
+	placement    []consistent.Partition
+	placed       map[string]uint64
+	topologyLock sync.RWMutex
+	// Merkle trees of the ranges synchronized with other servers, kept up to date on every write
+	trees     map[hashRange]*merkle.Tree
+	treesLock sync.Mutex
+	// merges of the same list take the same lock, so the trees see their digests in order
+	mergeLocks [mergeLockStripes]sync.Mutex
+	// file with the progress of the key transfers, and whether one is running; reads are
+	// refused until the server received its keys
+	checkpointsPath string
+	transferLock    sync.Mutex
+	joining         atomic.Bool
+	// held while the server hands its keys over to leave the ring or to change its weight
+	leaveLock sync.Mutex
 }
 
-func NewServer(port string, name string) *Server {
-	// generate a random node uuid
-	// open SQLite database
-	db, err := sql.Open("sqlite3", fmt.Sprintf("../node_storage/%s.db", name))
-	if err != nil {
-		fmt.Println("Error opening database:", err)
-		os.Exit(1)
-	}
-	fmt.Println("Opened database successfully")
+// Number of locks the merges are spread over by email hash
+const mergeLockStripes = 64
 
-	// create tables if not exists
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS shopping_lists (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			email TEXT NOT NULL,
-			email_hash TEXT NOT NULL,
-			shopping_list BLOB NOT NULL
-		);
-	`)
-	if err != nil {
-		fmt.Println("Error creating table:", err)
-		os.Exit(1)
+func NewServer(port string, name string, zone string, weight int, loadBalancers []string, transport rpc.Transport, store storage.Store) *Server {
+	return &Server{
+		port:            port,
+		name:            name,
+		address:         "localhost:" + port,
+		weight:          weight,
+		zone:            zone,
+		loadBalancers:   loadBalancers,
+		transport:       transport,
+		store:           store,
+		members:         membership.NewList(),
+		membershipPath:  fmt.Sprintf("../node_storage/%s.membership.json", name),
+		nodes:           []Node{},
+		trees:           make(map[hashRange]*merkle.Tree),
+		checkpointsPath: fmt.Sprintf("../node_storage/%s.transfers.json", name),
 	}
-
-	return &Server{port: port, name: name, loadBalancerIP: "localhost:8080", db: db, nodes: []Node{}}
 }
 
 func (s *Server) Run() {
 	// Print the node ID
 	fmt.Println("Name:", s.name)
-	// Connect to the load balancer with retries
+	// the ring as it was before a restart, until the gossip brings the current one
+	err := s.members.Load(s.membershipPath)
+	if err != nil {
+		fmt.Println("Ignoring invalid saved membership:", err)
+	}
+	s.applyMembership()
+	// Connect to a load balancer with retries
 	status := s.connectToLoadBalancerWithRetries(3, time.Second*2)
 	if status != http.StatusOK {
 		fmt.Println("Exiting...")
@@ -75,587 +111,1389 @@ func (s *Server) Run() {
 }
 
 func (s *Server) connectToLoadBalancerWithRetries(maxRetries int, retryInterval time.Duration) int {
-	url := fmt.Sprintf("http://%s/connect-node", s.loadBalancerIP)
-
 	for retry := 0; retry < maxRetries; retry++ {
-		// Create a buffer with the node ID and server port
-		body := strings.NewReader(fmt.Sprintf("%s,%s", s.name, "localhost:"+s.port))
-
-		resp, err := http.Post(url, "text/plain", body)
-		if err != nil {
-			fmt.Printf("Error connecting to the load balancer (retry %d/%d): %v\n", retry+1, maxRetries, err)
-			if retry == maxRetries-1 {
-				break
-			}
-			time.Sleep(retryInterval)
-			retryInterval *= 2
-			continue
-		}
-
-		defer func(Body io.ReadCloser) {
-			err := Body.Close()
+		// any load balancer will do, the next one is tried when one cannot be reached
+		for _, loadBalancer := range s.loadBalancers {
+			// Send the node ID and server address
+			membership, status, err := s.transport.ConnectNode(loadBalancer, &message.ConnectNode{ID: s.name, Address: s.address, Weight: s.weight, Zone: s.zone, Hash: s.members.Hash(), Partitioner: s.members.Partitioner()})
 			if err != nil {
-				fmt.Println(err)
+				fmt.Printf("Error connecting to the load balancer %s (retry %d/%d): %v\n", loadBalancer, retry+1, maxRetries, err)
+				continue
 			}
-		}(resp.Body)
-
-		if resp.StatusCode == http.StatusOK {
-			fmt.Println("Connected to the load balancer successfully.")
-			return resp.StatusCode
+			if status == http.StatusOK {
+				err = s.mergeMembership(membership)
+				if err != nil {
+					fmt.Printf("Refusing the ring of the load balancer %s: %v\n", loadBalancer, err)
+					continue
+				}
+				fmt.Println("Connected to the load balancer " + loadBalancer + " successfully.")
+				return status
+			}
+			fmt.Printf("Error connecting to the load balancer %s: %d\n", loadBalancer, status)
+		}
+		if retry == maxRetries-1 {
+			break
 		}
+		time.Sleep(retryInterval)
+		retryInterval *= 2
 	}
 
-	fmt.Printf("Max retries reached. Could not connect to the load balancer after %d attempts.\n", maxRetries)
+	fmt.Printf("Max retries reached. Could not connect to a load balancer after %d attempts.\n", maxRetries)
 	return http.StatusInternalServerError
 }
 
 func (s *Server) HandleShoppingListPut(writer http.ResponseWriter, request *http.Request) {
-	// split the request body into email and shopping list
-	fmt.Println("Handling shopping list put")
-	fmt.Println("")
-	err := request.ParseMultipartForm(32 << 20)
+	var put message.PutList
+	err := message.ReadRequest(request, &put)
+	if err != nil {
+		message.Error(writer, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	err = s.PutList(&put)
 	if err != nil {
-		http.Error(writer, "Error parsing request body", http.StatusBadRequest)
+		message.Fail(writer, err)
 		return
 	}
-	email := request.FormValue("email")
+	// send a success response to the load balancer
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) PutList(put *message.PutList) error {
+	fmt.Println("Handling shopping list put")
+	fmt.Println("")
+	email := put.Email
 	fmt.Println("Email:", email)
 
-	file, handler, err := request.FormFile("file")
+	err := s.checkEpoch(put.Epoch)
 	if err != nil {
-		http.Error(writer, "Error reading file", http.StatusBadRequest)
-		return
+		return err
 	}
-	defer func(file multipart.File) {
-		err := file.Close()
-		if err != nil {
-			fmt.Println(err)
-		}
-	}(file)
 
-	fmt.Println("File name:", handler.Filename)
+	emailHash := s.hasher().Hash([]byte(email))
 
-	hash := sha256.New()
-	hash.Write([]byte(email))
-	emailHash := hash.Sum(nil)
+	isDelta := put.Delta
+	fmt.Println("Delta:", isDelta)
 
-	row := s.db.QueryRow("SELECT shopping_list FROM shopping_lists WHERE email_hash = ?", string(emailHash))
-	var shoppingListDatabase []byte
-	row.Scan(&shoppingListDatabase)
-	// read the shopping list from the file
-	shoppingListClient, err := io.ReadAll(file)
-	if err != nil {
-		http.Error(writer, "Error reading file", http.StatusBadRequest)
-		return
+	// the list belongs to another replica that is down, keep it until it can be delivered
+	if put.Hint != "" {
+		err := s.storeHint(email, put.Hint, crdt.FromGOB64(put.List), isDelta)
+		if err != nil {
+			return message.Errorf(http.StatusInternalServerError, "Error storing hinted shopping list in database")
+		}
+		fmt.Println("Successfully stored shopping list on behalf of " + put.Hint)
+		return nil
 	}
 
 	// Join the shopping list from the database and the shopping list from the client
 	// using the CRDT implementation
-	if len(shoppingListDatabase) != 0 {
-		listClient := crdt.FromGOB64(string(shoppingListClient))
-		listDatabase := crdt.FromGOB64(string(shoppingListDatabase))
-		listDatabase.Join(listClient)
-		_, err = s.db.Exec("UPDATE shopping_lists SET shopping_list = ? WHERE email_hash = ?", []byte(listDatabase.ToGOB64()), string(emailHash))
-		if err != nil {
-			http.Error(writer, "Error updating shopping list in database", http.StatusInternalServerError)
-			return
-		}
-	} else {
-		// insert the shopping list into the database
-		_, err = s.db.Exec("INSERT INTO shopping_lists (email, email_hash, shopping_list) VALUES (?, ?, ?)", email, string(emailHash), shoppingListClient)
-		if err != nil {
-			http.Error(writer, "Error inserting shopping list into database", http.StatusInternalServerError)
-			return
-		}
+	err = s.mergeShoppingList(email, string(emailHash), crdt.FromGOB64(put.List), isDelta)
+	if err == storage.ErrMissingState {
+		return message.Errorf(http.StatusPreconditionFailed, "Full shopping list required")
+	}
+	if err != nil {
+		return message.Errorf(http.StatusInternalServerError, "Error storing shopping list in database")
 	}
-	// send a success response to the load balancer
-	writer.WriteHeader(http.StatusOK)
 	fmt.Println("Successfully inserted shopping list into database")
-
+	return nil
 }
 
 func (s *Server) HandleShoppingListGet(writer http.ResponseWriter, request *http.Request) {
 	// get the email from the url
-	fmt.Println("Handling shopping list get")
-	fmt.Println("")
 	email := strings.TrimPrefix(request.URL.Path, "/getListServer/")
-	fmt.Println("Email:", email)
-
-	hash := sha256.New()
-	hash.Write([]byte(email))
-	emailHash := hash.Sum(nil)
-
-	// get the shopping list from the database
-	row := s.db.QueryRow("SELECT shopping_list FROM shopping_lists WHERE email_hash = ?", string(emailHash))
-	var shoppingList []byte
-	err := row.Scan(&shoppingList)
+	// the epoch of the ring the load balancer routed the read with, zero when missing
+	epoch, _ := strconv.ParseUint(request.URL.Query().Get("epoch"), 10, 64)
+	list, err := s.GetList(email, epoch)
 	if err != nil {
-		http.Error(writer, "Error getting shopping list from database", http.StatusInternalServerError)
+		message.Fail(writer, err)
 		return
 	}
 	// send the shopping list to the load balancer
-	writer.WriteHeader(http.StatusOK)
-	_, err = writer.Write(shoppingList)
+	err = message.Write(writer, http.StatusOK, list)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 	fmt.Println("Successfully sent shopping list to load balancer")
-
 }
 
-func (s *Server) HandleNeighboursInformation(writer http.ResponseWriter, request *http.Request) {
-	// get the neighbours information from the request body
-	// "nodeId:NodehashId,frontNeighbour1:frontNeighbour1HashId,frontNeighbour2:frontNeighbour2HashId
-	//,backNeighbour1:backNeighbour1HashId,backNeighbour2:backNeighbour2HashId" and so on
-	fmt.Println("Handling neighbours information")
+func (s *Server) GetList(email string, epoch uint64) (*message.ShoppingList, error) {
+	fmt.Println("Handling shopping list get")
 	fmt.Println("")
+	fmt.Println("Email:", email)
 
-	body, err := io.ReadAll(request.Body)
+	err := s.checkEpoch(epoch)
 	if err != nil {
-		http.Error(writer, "Error reading request body", http.StatusBadRequest)
-		return
+		return nil, err
 	}
-	fmt.Println("Body:", string(body))
-	// split the body into lines
-	lines := strings.Split(string(body), "****")
-	newNodes := []Node{}
-	// iterate over the lines
-	for _, line := range lines {
-		node := Node{}
-		attributes := strings.Split(line, ",,,")
-		for i, attribute := range attributes {
-			if i == 0 {
-				node.id = strings.Split(attribute, ":::")[0]
-				node.hashId = []byte(strings.Split(attribute, ":::")[1])
-			} else if strings.Contains(attribute, "frontNeighbor1") {
-				frontNeighbor1 := Node{}
-				frontNeighbor1.id = strings.Split(attribute, ":::")[0]
-				frontNeighbor1.server = strings.Split(attribute, ":::")[1]
-				frontNeighbor1.hashId = []byte(strings.Split(attribute, ":::")[2])
-				node.frontNodes = append(node.frontNodes, frontNeighbor1)
-			} else if strings.Contains(attribute, "frontNeighbor2") {
-				frontNeighbor2 := Node{}
-				frontNeighbor2.id = strings.Split(attribute, ":::")[0]
-				frontNeighbor2.server = strings.Split(attribute, ":::")[1]
-				frontNeighbor2.hashId = []byte(strings.Split(attribute, ":::")[2])
-				node.frontNodes = append(node.frontNodes, frontNeighbor2)
-			} else if strings.Contains(attribute, "backNeighbor1") {
-				backNeighbor1 := Node{}
-				backNeighbor1.id = strings.Split(attribute, ":::")[0]
-				backNeighbor1.server = strings.Split(attribute, ":::")[1]
-				backNeighbor1.hashId = []byte(strings.Split(attribute, ":::")[2])
-				node.backNodes = append(node.backNodes, backNeighbor1)
-			} else if strings.Contains(attribute, "backNeighbor2") {
-				backNeighbor2 := Node{}
-				backNeighbor2.id = strings.Split(attribute, ":::")[0]
-				backNeighbor2.server = strings.Split(attribute, ":::")[1]
-				backNeighbor2.hashId = []byte(strings.Split(attribute, ":::")[2])
-				node.backNodes = append(node.backNodes, backNeighbor2)
-			}
-		}
-		newNodes = append(newNodes, node)
+
+	if s.joining.Load() {
+		return nil, message.Errorf(http.StatusServiceUnavailable, "Server is still receiving its keys")
 	}
-	// update the nodes array
-	s.nodes = newNodes
+
+	emailHash := s.hasher().Hash([]byte(email))
+
+	// get the shopping list from the store
+	stored, err := s.store.Get(string(emailHash))
+	if err == storage.ErrNotFound {
+		// an answer like any other, the list was never written to this replica
+		return nil, message.Errorf(http.StatusNotFound, "Shopping list not found")
+	}
+	if err != nil {
+		return nil, message.Errorf(http.StatusInternalServerError, "Error getting shopping list from database")
+	}
+	return &message.ShoppingList{Email: email, List: stored.List.ToGOB64()}, nil
 }
 
-func (s *Server) HandleRequestKeys(_ http.ResponseWriter, _ *http.Request) {
-	fmt.Println("Handling request keys")
-	for _, node := range s.nodes {
-		done := false
-		for i, frontNode := range node.frontNodes {
-			fmt.Println("Requesting my keys from front node number " + strconv.Itoa(i+1) + " with port " + frontNode.server)
-			nodeHash := node.hashId
-			firstBackNodeHash := node.backNodes[0].hashId
-			// send the node id and the first back node id to the first front node
-			body := strings.NewReader(fmt.Sprintf("%s,%s,%s", s.port, string(nodeHash), string(firstBackNodeHash)))
-			for attempt := 1; attempt <= 3; attempt++ {
-				resp, err := http.Post(fmt.Sprintf("http://%s/sendMeKeys", frontNode.server), "text/plain", body)
-				if err != nil {
-					fmt.Printf("Error on attempt %d: %s\n", attempt, err)
-					time.Sleep(time.Second * 2) // Adjust the delay between retries as needed
-					continue
-				}
+// HandleHealth answers health checks, the ring itself watches the heartbeats gossiped by the servers.
+func (s *Server) HandleHealth(writer http.ResponseWriter, _ *http.Request) {
+	writer.WriteHeader(http.StatusOK)
+}
 
-				defer func(Body io.ReadCloser) {
-					err := Body.Close()
-					if err != nil {
-						fmt.Println(err)
-					}
-				}(resp.Body)
-
-				if resp.StatusCode == http.StatusOK {
-					fmt.Println("Successfully sent request to front node number " + strconv.Itoa(i+1) + " with port " + frontNode.server)
-					done = true
-					break
-				}
+// Gossip settings, every round the server sends its membership to one random peer
+const (
+	gossipInterval = time.Second
+	gossipTimeout  = time.Second
+)
 
-				fmt.Printf("Attempt %d failed with status code: %d\n", attempt, resp.StatusCode)
-				time.Sleep(time.Second * 2) // Adjust the delay between retries as needed
-			}
-			if done {
-				break
-			}
+// gossip raises the heartbeat of this server and exchanges the membership with a random
+// server of the ring. The peer merges what it did not know and answers with its own membership.
+func (s *Server) gossip() {
+	s.members.Beat(s.name)
+	var peers []string
+	for _, member := range s.members.Membership().Members {
+		if member.ID != s.name && member.Status != message.Left {
+			peers = append(peers, member.Address)
 		}
-		fmt.Println("Done requesting keys from front nodes of node" + node.id)
+	}
+	if len(peers) > 0 {
+		s.gossipWith(peers[rand.Intn(len(peers))])
+	}
+	// a join that failed is taken up again
+	if member, exists := s.members.Get(s.name); exists && member.Status == message.Joining {
+		go s.join()
 	}
 }
 
-func (s *Server) HandleSendMeKeys(writer http.ResponseWriter, request *http.Request) {
-	// parse the request body
-	body, err := io.ReadAll(request.Body)
+func (s *Server) gossipWith(peer string) {
+	membership, status, err := s.transport.Gossip(peer, s.members.Membership(), gossipTimeout)
 	if err != nil {
-		http.Error(writer, "Error reading request body", http.StatusBadRequest)
+		// the load balancers notice when a server stops beating
 		return
 	}
-	serverPort := strings.Split(string(body), ",")[0]
-	fmt.Println("Sending requested keys to server with port " + string(serverPort))
-	nodeHash := strings.Split(string(body), ",")[1]
-	firstBackNodeHash := strings.Split(string(body), ",")[2]
-	if bytes.Compare([]byte(nodeHash), []byte(firstBackNodeHash)) == 1 {
-		// query the database for all the shopping lists
-		rows, err := s.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists where email_hash > ? AND email_hash <= ?", firstBackNodeHash, nodeHash)
-		if err != nil {
-			fmt.Println("Error querying database:", err)
-			return
-		}
-		defer func(rows *sql.Rows) {
-			err := rows.Close()
-			if err != nil {
-				fmt.Println(err)
-			}
-		}(rows)
-		// iterate over the shopping lists
-		for rows.Next() {
-			// send the row to the server with the specified port
-			var email string
-			var emailHash string
-			var shoppingList []byte
-			err = rows.Scan(&email, &emailHash, &shoppingList)
-			if err != nil {
-				fmt.Println("Error scanning row:", err)
-				return
-			}
-			fmt.Println("Sending shopping list with email " + email + " to server with port " + serverPort)
-			body := &bytes.Buffer{}
-			writer := multipart.NewWriter(body)
-			err = writer.WriteField("email", email)
-			if err != nil {
-				fmt.Println("Error writing email field:", err)
-				return
-			}
-			part, err := writer.CreateFormFile("file", email)
-			if err != nil {
-				fmt.Println("Error creating form file:", err)
-				return
-			}
-			_, err = part.Write(shoppingList)
-			if err != nil {
-				fmt.Println("Error writing to form file:", err)
-				return
-			}
-			err = writer.Close()
-			if err != nil {
-				fmt.Println("Error closing writer:", err)
-				return
-			}
+	if status != http.StatusOK {
+		fmt.Printf("Error gossiping with %s: %d\n", peer, status)
+		return
+	}
+	err = s.mergeMembership(membership)
+	if err != nil {
+		fmt.Printf("Refusing the ring of %s: %v\n", peer, err)
+	}
+}
 
-			req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:%s/putListServer", serverPort), body)
-			if err != nil {
-				fmt.Println("Error creating new request:", err)
-				return
-			}
-			req.Header.Set("Content-Type", writer.FormDataContentType())
-			resp, err := http.DefaultClient.Do(req)
-			if err != nil {
-				fmt.Println("Error sending request:", err)
-				return
-			}
-			err = resp.Body.Close()
-			if err != nil {
-				fmt.Println("Error closing response body:", err)
-				return
-			}
+func (s *Server) HandleGossip(writer http.ResponseWriter, request *http.Request) {
+	var membership message.Membership
+	err := message.ReadRequest(request, &membership)
+	if err != nil {
+		message.Error(writer, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	response, err := s.Gossip(&membership)
+	if err != nil {
+		message.Fail(writer, err)
+		return
+	}
+	err = message.Write(writer, http.StatusOK, response)
+	if err != nil {
+		fmt.Println("Error writing response:", err)
+	}
+}
 
-			// Check the response status code
-			if resp.StatusCode != http.StatusOK {
-				fmt.Println("Error sending file to server:", resp.Status)
-				return
-			}
+// Gossip merges the membership of a peer, a server or a load balancer, and answers with the
+// membership of this server.
+func (s *Server) Gossip(membership *message.Membership) (*message.Membership, error) {
+	err := s.mergeMembership(membership)
+	if err != nil {
+		return nil, message.Errorf(http.StatusConflict, "%v", err)
+	}
+	return s.members.Membership(), nil
+}
 
-			fmt.Println("Successfully sent shopping list" + email + " to server with port " + serverPort)
-		}
-	} else {
-		// query the database for all the shopping lists
-		rows, err := s.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists where email_hash > ? OR email_hash < ?", firstBackNodeHash, nodeHash)
-		if err != nil {
-			fmt.Println("Error querying database:", err)
-			return
-		}
-		defer func(rows *sql.Rows) {
-			err := rows.Close()
-			if err != nil {
-				fmt.Println(err)
-			}
-		}(rows)
-		// iterate over the shopping lists
-		for rows.Next() {
-			// send the row to the server with the specified port
-			var email string
-			var emailHash string
-			var shoppingList []byte
-			err = rows.Scan(&email, &emailHash, &shoppingList)
-			if err != nil {
-				fmt.Println("Error scanning row:", err)
-				return
-			}
-			body := &bytes.Buffer{}
-			writer := multipart.NewWriter(body)
-			err = writer.WriteField("email", email)
-			if err != nil {
-				fmt.Println("Error writing email field:", err)
-				return
-			}
-			part, err := writer.CreateFormFile("file", email)
-			if err != nil {
-				fmt.Println("Error creating form file:", err)
-				return
-			}
-			_, err = part.Write(shoppingList)
-			if err != nil {
-				fmt.Println("Error writing to form file:", err)
-				return
-			}
-			err = writer.Close()
-			if err != nil {
-				fmt.Println("Error closing writer:", err)
-				return
-			}
+// mergeMembership takes in the records of membership that are newer than the known ones and
+// moves to the new ring when it changed. The membership of a ring hashing keys with another
+// function is refused.
+func (s *Server) mergeMembership(membership *message.Membership) error {
+	changed, _, err := s.members.Merge(membership)
+	if err != nil {
+		return err
+	}
+	// only this server changes its record, a restart on another port moves it
+	if member, exists := s.members.Get(s.name); exists && member.Address != s.address {
+		s.members.Update(s.name, func(member *message.Member) {
+			member.Address = s.address
+		})
+		changed = true
+	}
+	if changed {
+		s.applyMembership()
+	}
+	return nil
+}
 
-			req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:%s/putListServer", serverPort), body)
-			if err != nil {
-				fmt.Println("Error creating new request:", err)
-				return
-			}
-			req.Header.Set("Content-Type", writer.FormDataContentType())
-			resp, err := http.DefaultClient.Do(req)
-			if err != nil {
-				fmt.Println("Error sending request:", err)
-				return
-			}
-			err = resp.Body.Close()
-			if err != nil {
-				fmt.Println("Error closing response body:", err)
-				return
-			}
+// newPartitioner is an empty partitioner placing keys as the load balancers set it, and the
+// members of the ring on it. Every member brings its own number of virtual nodes.
+func (s *Server) newPartitioner() consistent.Partitioner {
+	config := consistent.DefaultRingConfig()
+	// a membership saved before the replication factor was gossiped has none
+	if replicationFactor := s.members.ReplicationFactor(); replicationFactor > 0 {
+		config.ReplicationFactor = replicationFactor
+	}
+	config.Hasher = s.hasher()
+	partitioner, err := consistent.NewPartitioner(s.members.Partitioner(), config)
+	if err != nil {
+		fmt.Println("Placing keys on a ring:", err)
+		partitioner = consistent.NewRing(config)
+	}
+	partitioner.Restore(s.members.Ring())
+	return partitioner
+}
 
-			// Check the response status code
-			if resp.StatusCode != http.StatusOK {
-				fmt.Println("Error sending file to server:", resp.Status)
-				return
-			}
+// hasher is the hash function of the ring. Lists are stored under the hash of their email,
+// so the key ranges of the ring select them.
+func (s *Server) hasher() consistent.Hasher {
+	hasher, err := consistent.NewHasher(s.members.Hash())
+	if err != nil {
+		fmt.Println("Hashing with SHA-256:", err)
+		return consistent.SHA256{}
+	}
+	return hasher
+}
 
-			fmt.Println("Successfully sent shopping list" + email + " to server with port " + serverPort)
+// applyMembership places the members with the partitioner of the ring and takes the ranges
+// this server owns from it, as nodes with the replicas of the range as front neighbours. No
+// nodes means the server does not belong to the ring.
+func (s *Server) applyMembership() {
+	partitions, epoch := s.newPartitioner().Partitions()
+	versions := s.memberVersions()
+	newNodes := []Node{}
+	// the ranges this server keeps a copy of, as the owner or as a replica
+	held := make(map[hashRange]bool)
+	for _, partition := range partitions {
+		owner := partition.Nodes[0]
+		owned := owner.Id == s.name || owner.RealNodeId == s.name
+		replicated := false
+		for _, frontNode := range partition.Nodes[1:] {
+			if frontNode.Id == s.name || frontNode.RealNodeId == s.name {
+				replicated = true
+			}
+		}
+		if owned || replicated {
+			held[hashRange{start: string(partition.Range.Start), end: string(partition.Range.End)}] = true
+		}
+		if !owned {
+			continue
+		}
+		newNode := Node{id: owner.Id, hashId: partition.Range.End, rangeStart: partition.Range.Start, server: owner.Server}
+		for _, frontNode := range partition.Nodes[1:] {
+			newNode.frontNodes = append(newNode.frontNodes, Node{id: frontNode.Id, server: frontNode.Server, hashId: frontNode.HashId})
 		}
+		newNodes = append(newNodes, newNode)
 	}
-}
 
-func (server *Server) retrieveCRDTsInRange(startHash, endHash string) ([]string, error) {
-	var crdts []string
-	var rows *sql.Rows
-	var err error
-	if startHash < endHash {
-		rows, err = server.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists where email_hash > ? AND email_hash < ?", startHash, endHash)
-	} else {
-		rows, err = server.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists where email_hash > ? OR email_hash <= ?", startHash, endHash)
+	s.topologyLock.Lock()
+	if epoch < s.epoch {
+		// the membership changed again in the meantime, the newer ring is already in place
+		s.topologyLock.Unlock()
+		return
 	}
+	previous, previousVersions := s.placement, s.placed
+	s.nodes = newNodes
+	s.epoch = epoch
+	s.placement = partitions
+	s.placed = versions
+	s.topologyLock.Unlock()
+	s.evictTrees(held)
+	fmt.Printf("Moved to the ring of epoch %d, holding %d nodes\n", epoch, len(newNodes))
 
+	err := s.members.Save(s.membershipPath)
 	if err != nil {
-		fmt.Println("Error querying database:", err)
-		return nil, err
+		fmt.Println("Error saving membership:", err)
 	}
-	defer func(rows *sql.Rows) {
-		err := rows.Close()
-		if err != nil {
-			fmt.Println(err)
-		}
-	}(rows)
-
-	// iterate over the shopping lists
-	for rows.Next() {
-		// send the row to the server with the specified port
-		var email string
-		var emailHash string
-		var shoppingList []byte
-		err = rows.Scan(&email, &emailHash, &shoppingList)
-		if err != nil {
-			return nil, err
+	member, exists := s.members.Get(s.name)
+	if exists && member.Status == message.Joining {
+		go s.join()
+	} else if exists && member.Status == message.Ready && previous != nil {
+		if moved := s.movedToMe(previous, partitions, previousVersions, versions); len(moved) > 0 {
+			go s.pullRanges(moved)
 		}
-		crdt := string(shoppingList) + "####" + email + "####" + emailHash
-		crdts = append(crdts, crdt)
 	}
-
-	return crdts, nil
 }
 
-func (server *Server) Sync() {
-	for _, node := range server.nodes {
-		for _, frontNeighbor := range node.frontNodes {
-			fmt.Println("Sending hash space between the first back neighbour and the node itself to the front neighbor with port " + frontNeighbor.server)
-			var crdts []string // Use your actual CRDT type
-			crdts, _ = server.retrieveCRDTsInRange(string(node.hashId), string(node.backNodes[0].hashId))
+// memberVersions returns the version of the record of every server by address.
+func (s *Server) memberVersions() map[string]uint64 {
+	versions := make(map[string]uint64)
+	for _, member := range s.members.Membership().Members {
+		versions[member.Address] = member.Version
+	}
+	return versions
+}
 
-			
-			additionalData := strings.Join(crdts, "++++")
+// movedToMe returns the ranges the change from the placement before to the placement after
+// gives this server from servers whose record did not change. A server that joins, leaves or
+// changes its weight moves its own ranges, but other partitioners than the ring also move
+// ranges between servers that did not change, which nobody sends.
+func (s *Server) movedToMe(before, after []consistent.Partition, beforeVersions, afterVersions map[string]uint64) []consistent.Transfer {
+	var moved []consistent.Transfer
+	for _, transfer := range consistent.OwnerChanges(before, after) {
+		if transfer.To != s.address || transfer.From == s.address {
+			continue
+		}
+		if version, exists := beforeVersions[transfer.From]; exists && version == afterVersions[transfer.From] {
+			moved = append(moved, transfer)
+		}
+	}
+	return moved
+}
 
-			requestBody := fmt.Sprintf("%s****%s", string(node.hashId), string(node.backNodes[0].hashId))
-			if len(additionalData) > 0 {
-				requestBody += "****" + additionalData
-			}
+// pullRanges receives the ranges movedToMe found, after the transfers already running.
+func (s *Server) pullRanges(transfers []consistent.Transfer) {
+	s.transferLock.Lock()
+	defer s.transferLock.Unlock()
+	fmt.Printf("Pulling %d ranges moved to me\n", len(transfers))
+	err := s.receiveRanges(transfers)
+	if err != nil {
+		fmt.Println("Error pulling the ranges moved to me:", err)
+	}
+}
 
-			body := strings.NewReader(requestBody)
-			resp, err := http.Post(fmt.Sprintf("http://%s/syncShoppingList", frontNeighbor.server), "text/plain", body)
-			if err != nil {
-				fmt.Println("Error sending request:", err)
-				continue
-			}
-			defer resp.Body.Close()
+// join receives the keys of this server, then tells the ring it serves reads.
+func (s *Server) join() {
+	if !s.transferLock.TryLock() {
+		// already receiving them
+		return
+	}
+	err := s.receiveKeys()
+	s.transferLock.Unlock()
+	if err != nil {
+		fmt.Println("Error receiving my keys, retrying:", err)
+		return
+	}
+	updated := s.members.Update(s.name, func(member *message.Member) {
+		if member.Status == message.Joining {
+			member.Status = message.Ready
+		}
+	})
+	if updated {
+		fmt.Println("Received my keys, joined the ring")
+		s.applyMembership()
+		s.spreadMembership()
+	}
+}
 
-			responseData, err := io.ReadAll(resp.Body)
-			if err != nil {
-				fmt.Println("Error reading response body:", err)
-				continue
-			}
-			if len(responseData) != 0 {
-				for _, shoppingList := range strings.Split(string(responseData), "****") {
-					receivedShoppingList := crdt.FromGOB64(strings.Split(shoppingList, "####")[0])
-					email := strings.Split(shoppingList, "####")[1]
-					
-					emailHash := strings.Split(shoppingList, "####")[2]
-					row := server.db.QueryRow("SELECT shopping_list FROM shopping_lists WHERE email_hash = ?", emailHash)
-					var shoppingListDatabase []byte
-					row.Scan(&shoppingListDatabase)
-					if len(shoppingListDatabase) != 0 {
-						listDatabase := crdt.FromGOB64(string(shoppingListDatabase))
-						listDatabase.Join(receivedShoppingList)
-						newShoppingList := []byte(listDatabase.ToGOB64())
-						_, err = server.db.Exec("UPDATE shopping_lists SET shopping_list = ? WHERE email_hash = ?", string(newShoppingList), emailHash)
-						if err != nil {
-							fmt.Println("Error updating shopping list in database:", err)
-							continue
-						}
-					} else {
-						_, err = server.db.Exec("INSERT INTO shopping_lists (email, email_hash, shopping_list) VALUES (?, ?, ?)", email, emailHash, string(shoppingList))
-						if err != nil {
-							fmt.Println("Error inserting shopping list into database:", err)
-							return
-						}
-					}
-				}
-			}
+// spreadMembership sends the membership to every other server at once, so a change of this
+// server does not wait for the gossip rounds to reach them.
+func (s *Server) spreadMembership() {
+	for _, member := range s.members.Membership().Members {
+		if member.ID != s.name && member.Status != message.Left {
+			s.gossipWith(member.Address)
 		}
 	}
 }
 
-func (s *Server) HandleSyncShoppingList(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
+func (s *Server) HandleLeave(writer http.ResponseWriter, request *http.Request) {
+	var leave message.DisconnectNode
+	err := message.ReadRequest(request, &leave)
 	if err != nil {
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		message.Error(writer, "Error parsing request body", http.StatusBadRequest)
 		return
 	}
-	defer r.Body.Close()
-
-	hashes := strings.Split(string(body), "****")
-	if len(hashes) < 2 {
-		http.Error(w, "Invalid hash range", http.StatusBadRequest)
+	err = s.Leave(&leave)
+	if err != nil {
+		message.Fail(writer, err)
 		return
 	}
-	startHash, endHash := hashes[0], hashes[1]
+	writer.WriteHeader(http.StatusOK)
+}
+
+// Leave streams every range this server holds to the servers that take it over once the
+// server is gone, then marks the server as left. The server keeps answering, but no
+// longer belongs to the ring.
+func (s *Server) Leave(leave *message.DisconnectNode) error {
+	if leave.ID != s.name {
+		return message.Errorf(http.StatusNotFound, "This server is %s, not %s", s.name, leave.ID)
+	}
+	s.leaveLock.Lock()
+	defer s.leaveLock.Unlock()
+	member, exists := s.members.Get(s.name)
+	if !exists || member.Status == message.Left {
+		return message.Errorf(http.StatusNotFound, "Server %s is not in the ring", s.name)
+	}
+	fmt.Println("Leaving the ring")
 
-	var additionalCRDTs []string
-	if len(hashes) > 2 {
-		additionalCRDTs = strings.Split(hashes[2], "++++")
+	// Work out which ranges change owner before touching the ring
+	transfers, err := s.newPartitioner().Plan(consistent.Change{Remove: []string{s.name}})
+	if err != nil {
+		return message.Errorf(http.StatusConflict, "%s", err.Error())
+	}
+	for _, transfer := range transfers {
+		// ranges moving between other servers are pulled by the servers gaining them
+		if transfer.From != s.address {
+			continue
+		}
+		err = s.TransferKeys(&message.KeyTransfer{To: transfer.To, Range: message.KeyRange{Start: transfer.Range.Start, End: transfer.Range.End}})
+		if err != nil {
+			return err
+		}
 	}
 
-	var rows *sql.Rows
-	if startHash < endHash {
-		rows, err = s.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists where email_hash > ? AND email_hash <= ?", string(startHash), string(endHash))
-	} else {
-		rows, err = s.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists where email_hash > ? OR email_hash <= ?", string(startHash), string(endHash))
+	// Only leave once the data lives somewhere else
+	s.members.Update(s.name, func(member *message.Member) {
+		member.Status = message.Left
+	})
+	s.applyMembership()
+	s.spreadMembership()
+	fmt.Println("Handed my keys over and left the ring")
+	return nil
+}
+
+func (s *Server) HandleSetWeight(writer http.ResponseWriter, request *http.Request) {
+	var weight message.NodeWeight
+	err := message.ReadRequest(request, &weight)
+	if err != nil {
+		message.Error(writer, "Error parsing request body", http.StatusBadRequest)
+		return
 	}
+	err = s.SetWeight(&weight)
 	if err != nil {
-		http.Error(w, "Error querying database", http.StatusInternalServerError)
+		message.Fail(writer, err)
 		return
 	}
-	defer rows.Close()
+	writer.WriteHeader(http.StatusOK)
+}
+
+// SetWeight moves this server to the virtual nodes of a new weight. The ranges it loses are
+// streamed to the servers taking them over before the change is gossiped. The ranges it gains
+// are pulled after, as a joining server, and reads are refused until they arrived; if pulling
+// them fails the server receives all its keys again like any joining server.
+func (s *Server) SetWeight(weight *message.NodeWeight) error {
+	if weight.ID != s.name {
+		return message.Errorf(http.StatusNotFound, "This server is %s, not %s", s.name, weight.ID)
+	}
+	if weight.Weight < 1 || weight.VirtualNodes < 0 {
+		return message.Errorf(http.StatusBadRequest, "Invalid weight %d with %d virtual nodes", weight.Weight, weight.VirtualNodes)
+	}
+	s.leaveLock.Lock()
+	defer s.leaveLock.Unlock()
+	member, exists := s.members.Get(s.name)
+	if !exists || member.Status == message.Left {
+		return message.Errorf(http.StatusNotFound, "Server %s is not in the ring", s.name)
+	}
+	if member.Status == message.Joining {
+		return message.Errorf(http.StatusConflict, "Server %s is still receiving its keys", s.name)
+	}
+	fmt.Printf("Moving from %d to %d virtual nodes\n", member.VirtualNodes, weight.VirtualNodes)
 
-	var crdts []string
-	for rows.Next() {
-		var email string
-		var emailHash string
-		var shoppingList []byte
-		err = rows.Scan(&email, &emailHash, &shoppingList)
+	resized := consistent.Member{ID: s.name, Server: member.Address, VirtualNodes: weight.VirtualNodes, Zone: member.Zone}
+	transfers, err := s.newPartitioner().Plan(consistent.Change{Add: []consistent.Member{resized}})
+	if err != nil {
+		return message.Errorf(http.StatusConflict, "%s", err.Error())
+	}
+	var gained []consistent.Transfer
+	for _, transfer := range transfers {
+		if transfer.To == s.address {
+			gained = append(gained, transfer)
+			continue
+		}
+		// ranges moving between other servers are pulled by the servers gaining them
+		if transfer.From != s.address {
+			continue
+		}
+		err = s.TransferKeys(&message.KeyTransfer{To: transfer.To, Range: message.KeyRange{Start: transfer.Range.Start, End: transfer.Range.End}})
 		if err != nil {
-			http.Error(w, "Error scanning row", http.StatusInternalServerError)
-			return
+			return err
 		}
-		crdt := string(shoppingList) + "####" + email + "####" + emailHash
-		crdts = append(crdts, crdt)
 	}
 
-	
+	if len(gained) == 0 {
+		s.members.Update(s.name, func(member *message.Member) {
+			member.VirtualNodes = weight.VirtualNodes
+			member.Weight = weight.Weight
+		})
+		s.applyMembership()
+		s.spreadMembership()
+		fmt.Printf("Now holding %d virtual nodes\n", weight.VirtualNodes)
+		return nil
+	}
 
-	response := strings.Join(crdts, "****")
-	_, err = w.Write([]byte(response))
+	// the join started by the new ring waits for the ranges pulled here
+	s.transferLock.Lock()
+	s.joining.Store(true)
+	s.members.Update(s.name, func(member *message.Member) {
+		member.VirtualNodes = weight.VirtualNodes
+		member.Weight = weight.Weight
+		member.Status = message.Joining
+	})
+	s.applyMembership()
+	s.spreadMembership()
+	err = s.receiveRanges(gained)
+	if err == nil {
+		s.joining.Store(false)
+		s.members.Update(s.name, func(member *message.Member) {
+			member.Status = message.Ready
+		})
+	}
+	s.transferLock.Unlock()
 	if err != nil {
-		http.Error(w, "Error writing response", http.StatusInternalServerError)
+		fmt.Println("Error receiving my new ranges, receiving all my keys:", err)
+		go s.join()
+		return nil
 	}
+	s.applyMembership()
+	s.spreadMembership()
+	fmt.Printf("Now holding %d virtual nodes\n", weight.VirtualNodes)
+	return nil
+}
 
-	//update the database
-	if len(additionalCRDTs) != 0 {
-		for _, additionalCRDT := range additionalCRDTs {
-			receivedCRDT := strings.Split(additionalCRDT, "####")[0]
-			email := strings.Split(additionalCRDT, "####")[1]
-			emailHash := strings.Split(additionalCRDT, "####")[2]
-			receivedShoppingList := crdt.FromGOB64(string(receivedCRDT))
-			row := s.db.QueryRow("SELECT shopping_list FROM shopping_lists WHERE email_hash = ?", emailHash)
-			var shoppingListDatabase []byte
-			row.Scan(&shoppingListDatabase)
-			if len(shoppingListDatabase) != 0 {
-				listDatabase := crdt.FromGOB64(string(additionalCRDT))
-				listDatabase.Join(receivedShoppingList)
-				newShoppingList := []byte(listDatabase.ToGOB64())
-				_, err = s.db.Exec("UPDATE shopping_lists SET shopping_list = ? WHERE email_hash = ?", string(newShoppingList), emailHash)
-				if err != nil {
-					fmt.Println("Error updating shopping list in database:", err)
-					continue
-				}
-			} else {
-				_, err = s.db.Exec("INSERT INTO shopping_lists (email, email_hash, shopping_list) VALUES (?, ?, ?)", email, emailHash, string(additionalCRDT))
-				if err != nil {
-					fmt.Println("Error inserting shopping list into database:", err)
-					return
-				}
-			}
+// receiveRanges pulls every transfer from its source, in batches.
+func (s *Server) receiveRanges(transfers []consistent.Transfer) error {
+	checkpoints := s.loadCheckpoints()
+	for _, transfer := range transfers {
+		keyRange := message.KeyRange{Start: transfer.Range.Start, End: transfer.Range.End}
+		checkpoint := checkpoints[checkpointKey(keyRange)]
+		if checkpoint.Done {
+			continue
+		}
+		_, err := s.fetchBatches(transfer.From, keyRange, checkpoint.After, checkpoints)
+		if err != nil {
+			return err
 		}
 	}
+	err := os.Remove(s.checkpointsPath)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Println("Error removing transfer checkpoints:", err)
+	}
+	return nil
+}
 
+// topology returns the nodes of this server and the epoch of the ring they come from.
+func (s *Server) topology() ([]Node, uint64) {
+	s.topologyLock.RLock()
+	defer s.topologyLock.RUnlock()
+	return s.nodes, s.epoch
 }
 
-func main() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: ./server <port> <name>")
-		return
+// checkEpoch refuses a request routed with an older ring than the one this server knows,
+// the sender has to route it again. Requests that were not routed carry no epoch.
+func (s *Server) checkEpoch(epoch uint64) error {
+	_, current := s.topology()
+	if epoch != 0 && epoch < current {
+		return message.Errorf(http.StatusConflict, "Stale ring epoch %d, the server is at epoch %d, retry with the current ring", epoch, current)
+	}
+	return nil
+}
+
+// receiveKeys pulls the ranges this server owns or replicates from the servers that held
+// them before it joined, in batches. Reads are refused until every range arrived, a failed transfer
+// resumes from its checkpoint on the next attempt. The caller holds transferLock.
+func (s *Server) receiveKeys() error {
+	s.joining.Store(true)
+	fmt.Println("Receiving my keys")
+
+	transfers, err := s.joinTransfers()
+	if err != nil {
+		return err
+	}
+	checkpoints := s.loadCheckpoints()
+	complete := true
+	for _, transfer := range transfers {
+		keyRange := message.KeyRange{Start: transfer.Range.Start, End: transfer.Range.End}
+		checkpoint := checkpoints[checkpointKey(keyRange)]
+		if checkpoint.Done {
+			continue
+		}
+		err := s.fetchRange(s.sourcesOf(transfer), keyRange, checkpoint.After, checkpoints)
+		if err != nil {
+			fmt.Printf("Error receiving the keys of (%x, %x]: %s\n", keyRange.Start, keyRange.End, err)
+			complete = false
+			continue
+		}
+		fmt.Printf("Done receiving the keys of (%x, %x]\n", keyRange.Start, keyRange.End)
+	}
+	if !complete {
+		return message.Errorf(http.StatusBadGateway, "Could not receive every key range")
+	}
+
+	// a later join starts over
+	err = os.Remove(s.checkpointsPath)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Println("Error removing transfer checkpoints:", err)
+	}
+	s.joining.Store(false)
+	fmt.Println("Received all my keys, serving reads")
+	return nil
+}
+
+// Number of shopping lists fetched per request during a key transfer
+const transferBatchSize = 100
+
+// joinTransfers returns the ranges this server owns or replicates that change hands when it
+// joins the other servers, each from a server that held it. Like the leave and weight
+// transfers they are the owner changes of the plan, replicas included.
+func (s *Server) joinTransfers() ([]consistent.Transfer, error) {
+	partitioner := s.newPartitioner()
+	members, epoch := partitioner.Members()
+	var others []consistent.Member
+	var joined []consistent.Member
+	for _, member := range members {
+		if member.ID == s.name {
+			joined = append(joined, member)
+		} else {
+			others = append(others, member)
+		}
+	}
+	if len(joined) == 0 {
+		return nil, nil
+	}
+	partitioner.Restore(others, epoch)
+	transfers, err := partitioner.Plan(consistent.Change{Add: joined})
+	if err != nil {
+		return nil, err
+	}
+	var mine []consistent.Transfer
+	for _, transfer := range transfers {
+		if transfer.To == s.address {
+			mine = append(mine, transfer)
+		}
+	}
+	return mine, nil
+}
+
+// sourcesOf returns the servers transfer can be pulled from: the server it comes from, then
+// the other servers holding its range in the current placement.
+func (s *Server) sourcesOf(transfer consistent.Transfer) []string {
+	sources := []string{transfer.From}
+	s.topologyLock.RLock()
+	placement := s.placement
+	s.topologyLock.RUnlock()
+	for _, partition := range placement {
+		if !storage.InRange(string(transfer.Range.End), string(partition.Range.Start), string(partition.Range.End)) {
+			continue
+		}
+		for _, node := range partition.Nodes {
+			if node.Server != transfer.From && node.Server != s.address {
+				sources = append(sources, node.Server)
+			}
+		}
+		break
+	}
+	return sources
+}
+
+// fetchRange pulls keyRange from sources, starting after the hash after. Any replica of the
+// range can serve it, so a failed source is replaced by the next one.
+func (s *Server) fetchRange(sources []string, keyRange message.KeyRange, after message.Hash, checkpoints map[string]transferCheckpoint) error {
+	var err error
+	for i, source := range sources {
+		fmt.Println("Requesting my keys from source number " + strconv.Itoa(i+1) + " with port " + source)
+		for attempt := 1; attempt <= 3; attempt++ {
+			after, err = s.fetchBatches(source, keyRange, after, checkpoints)
+			if err == nil {
+				return nil
+			}
+			fmt.Printf("Error on attempt %d: %s\n", attempt, err)
+			time.Sleep(time.Second * 2)
+		}
+	}
+	return err
+}
+
+// fetchBatches merges the lists of keyRange from source batch by batch, saving the hash
+// reached after every batch, and returns how far it got.
+func (s *Server) fetchBatches(source string, keyRange message.KeyRange, after message.Hash, checkpoints map[string]transferCheckpoint) (message.Hash, error) {
+	received := 0
+	for {
+		request := &message.FetchKeys{Range: keyRange, After: after, Limit: transferBatchSize}
+		batch, status, err := s.transport.FetchKeys(source, request)
+		if err != nil {
+			return after, err
+		}
+		if status != http.StatusOK {
+			return after, message.UnexpectedStatus(source, status)
+		}
+		for _, list := range batch.Lists {
+			err = s.mergeShoppingList(list.Email, string(list.EmailHash), crdt.FromGOB64(list.List), false)
+			if err != nil {
+				return after, err
+			}
+			after = list.EmailHash
+		}
+		received += len(batch.Lists)
+
+		done := batch.Done || len(batch.Lists) == 0
+		checkpoints[checkpointKey(keyRange)] = transferCheckpoint{After: after, Done: done}
+		err = s.saveCheckpoints(checkpoints)
+		if err != nil {
+			return after, err
+		}
+		progress := 100.0
+		if !done {
+			progress = 100 * rangeProgress(keyRange, after)
+		}
+		fmt.Printf("Received %d shopping lists from %s, %.1f%% of the range\n", received, source, progress)
+		if done {
+			return after, nil
+		}
+	}
+}
+
+// transferCheckpoint is how far the transfer of a key range got, the hash of the last list received.
+type transferCheckpoint struct {
+	After message.Hash `json:"after"`
+	Done  bool         `json:"done"`
+}
+
+func checkpointKey(keyRange message.KeyRange) string {
+	return hex.EncodeToString(keyRange.Start) + "-" + hex.EncodeToString(keyRange.End)
+}
+
+// loadCheckpoints reads the checkpoints left by an interrupted transfer, there are none after a complete one.
+func (s *Server) loadCheckpoints() map[string]transferCheckpoint {
+	checkpoints := make(map[string]transferCheckpoint)
+	data, err := os.ReadFile(s.checkpointsPath)
+	if err != nil {
+		return checkpoints
+	}
+	err = json.Unmarshal(data, &checkpoints)
+	if err != nil {
+		fmt.Println("Ignoring invalid transfer checkpoints:", err)
+		return make(map[string]transferCheckpoint)
+	}
+	return checkpoints
+}
+
+func (s *Server) saveCheckpoints(checkpoints map[string]transferCheckpoint) error {
+	data, err := json.Marshal(checkpoints)
+	if err != nil {
+		return err
+	}
+	// replace the file at once so a crash never leaves half of it
+	temporary := s.checkpointsPath + ".tmp"
+	err = os.WriteFile(temporary, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporary, s.checkpointsPath)
+}
+
+// rangeProgress is the share of keyRange that lies before the hash reached, going around the ring.
+func rangeProgress(keyRange message.KeyRange, reached message.Hash) float64 {
+	if len(reached) == 0 {
+		return 0
+	}
+	ring := new(big.Int).Lsh(big.NewInt(1), uint(8*len(keyRange.End)))
+	start := new(big.Int).SetBytes(keyRange.Start)
+	size := new(big.Int).Sub(new(big.Int).SetBytes(keyRange.End), start)
+	size.Mod(size, ring)
+	if size.Sign() == 0 {
+		// the range is the whole ring
+		size = ring
+	}
+	covered := new(big.Int).Sub(new(big.Int).SetBytes(reached), start)
+	covered.Mod(covered, ring)
+	progress, _ := new(big.Rat).SetFrac(covered, size).Float64()
+	return progress
+}
+
+func (s *Server) HandleFetchKeys(writer http.ResponseWriter, request *http.Request) {
+	var fetch message.FetchKeys
+	err := message.ReadRequest(request, &fetch)
+	if err != nil {
+		message.Error(writer, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	batch, err := s.FetchKeys(&fetch)
+	if err != nil {
+		message.Fail(writer, err)
+		return
+	}
+	err = message.Write(writer, http.StatusOK, batch)
+	if err != nil {
+		fmt.Println("Error writing response:", err)
+	}
+}
+
+// FetchKeys answers with the next batch of the shopping lists in request.Range, in ring order.
+func (s *Server) FetchKeys(request *message.FetchKeys) (*message.KeyBatch, error) {
+	if request.Limit <= 0 {
+		return nil, message.Errorf(http.StatusBadRequest, "Invalid batch size")
+	}
+	// after the end of the range there is nothing left, and (end, end] would be the whole ring
+	if len(request.After) != 0 && bytes.Equal(request.After, request.Range.End) {
+		return &message.KeyBatch{Done: true}, nil
+	}
+	start := request.Range.Start
+	if len(request.After) != 0 {
+		start = request.After
+	}
+	lists, err := s.store.Scan(string(start), string(request.Range.End), request.Limit)
+	if err != nil {
+		return nil, message.Errorf(http.StatusInternalServerError, "Error querying database")
+	}
+	batch := &message.KeyBatch{Done: len(lists) < request.Limit}
+	for _, stored := range lists {
+		batch.Lists = append(batch.Lists, message.StoredList{Email: stored.Email, EmailHash: message.Hash(stored.EmailHash), List: stored.List.ToGOB64()})
+	}
+	if len(lists) > 0 && lists[len(lists)-1].EmailHash == string(request.Range.End) {
+		batch.Done = true
+	}
+	return batch, nil
+}
+
+func (s *Server) HandleSendMeKeys(writer http.ResponseWriter, request *http.Request) {
+	// parse the request body
+	var transfer message.KeyTransfer
+	err := message.ReadRequest(request, &transfer)
+	if err != nil {
+		message.Error(writer, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	err = s.TransferKeys(&transfer)
+	if err != nil {
+		message.Fail(writer, err)
+		return
+	}
+	writer.WriteHeader(http.StatusOK)
+}
+
+// TransferKeys sends the shopping lists in the range of transfer to the server transfer.To,
+// batch by batch with the cursor of FetchKeys, so the range is never held in memory at once.
+func (s *Server) TransferKeys(transfer *message.KeyTransfer) error {
+	fmt.Println("Sending requested keys to server " + transfer.To)
+
+	sent := 0
+	var after message.Hash
+	for {
+		batch, err := s.FetchKeys(&message.FetchKeys{Range: transfer.Range, After: after, Limit: transferBatchSize})
+		if err != nil {
+			return err
+		}
+		var puts []*message.PutList
+		for _, list := range batch.Lists {
+			puts = append(puts, &message.PutList{Email: list.Email, List: list.List})
+			after = list.EmailHash
+		}
+		if len(puts) > 0 {
+			status, err := s.transport.SendKeys(transfer.To, puts)
+			if err == nil && status != http.StatusOK {
+				err = message.UnexpectedStatus(transfer.To, status)
+			}
+			if err != nil {
+				fmt.Println("Error sending shopping lists:", err)
+				return message.Errorf(http.StatusBadGateway, "Error sending shopping lists")
+			}
+			sent += len(puts)
+		}
+		if batch.Done || len(batch.Lists) == 0 {
+			break
+		}
+		fmt.Printf("Sent %d shopping lists to server %s, %.1f%% of the range\n", sent, transfer.To, 100*rangeProgress(transfer.Range, after))
+	}
+	fmt.Printf("Successfully sent %d shopping lists to server %s\n", sent, transfer.To)
+	return nil
+}
+
+func (s *Server) HandleSendKeys(writer http.ResponseWriter, request *http.Request) {
+	var lists message.PutLists
+	err := message.ReadRequest(request, &lists)
+	if err != nil {
+		message.Error(writer, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	for _, list := range lists.Lists {
+		err = s.PutList(list)
+		if err != nil {
+			message.Fail(writer, err)
+			return
+		}
+	}
+	writer.WriteHeader(http.StatusOK)
+}
+
+// sendShoppingList sends a GOB64 shopping list, or a delta of it, to server.
+func (s *Server) sendShoppingList(server string, email string, shoppingList string, isDelta bool) error {
+	status, err := s.transport.PutList(server, &message.PutList{Email: email, List: shoppingList, Delta: isDelta})
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return message.UnexpectedStatus(server, status)
+	}
+	return nil
+}
+
+// mergeShoppingList joins received into the stored list for emailHash, storing it when there is none.
+func (s *Server) mergeShoppingList(email string, emailHash string, received *crdt.List, isDelta bool) error {
+	lock := s.mergeLock(emailHash)
+	lock.Lock()
+	defer lock.Unlock()
+	merged, err := s.store.Merge(email, emailHash, received, isDelta)
+	if err != nil {
+		if err != storage.ErrMissingState {
+			fmt.Println("Error storing shopping list:", err)
+		}
+		return err
+	}
+	s.updateTrees(emailHash, merged.Digest())
+	return nil
+}
+
+func (s *Server) mergeLock(emailHash string) *sync.Mutex {
+	hash := fnv.New32a()
+	hash.Write([]byte(emailHash))
+	return &s.mergeLocks[hash.Sum32()%mergeLockStripes]
+}
+
+// storeHint keeps a shopping list meant for intendedServer, joining it with any hint already held for it.
+func (s *Server) storeHint(email string, intendedServer string, received *crdt.List, isDelta bool) error {
+	err := s.store.AddHint(storage.Hint{Email: email, IntendedServer: intendedServer, List: received.ToGOB64(), IsDelta: isDelta})
+	if err != nil {
+		fmt.Println("Error storing hinted shopping list:", err)
+	}
+	return err
+}
+
+// deliverHints sends the hinted shopping lists to their intended servers and drops the ones delivered.
+func (s *Server) deliverHints() {
+	hints, err := s.store.Hints()
+	if err != nil {
+		fmt.Println("Error reading hinted shopping lists:", err)
+		return
+	}
+
+	for _, hint := range hints {
+		// the intended server left the ring, its new owners get the list from the replicas
+		if !s.isMember(hint.IntendedServer) {
+			err = s.store.RemoveHint(hint)
+			if err != nil {
+				fmt.Println("Error deleting hinted shopping list:", err)
+				continue
+			}
+			fmt.Println("Dropped hinted shopping list " + hint.Email + " for " + hint.IntendedServer + ", which is no longer a member")
+			continue
+		}
+		err = s.deliverHint(hint)
+		if err != nil {
+			fmt.Println("Could not deliver hinted shopping list "+hint.Email+" to "+hint.IntendedServer+":", err)
+			continue
+		}
+		// a hint updated while it was being delivered is kept for the next round
+		err = s.store.RemoveHint(hint)
+		if err != nil {
+			fmt.Println("Error deleting hinted shopping list:", err)
+			continue
+		}
+		fmt.Println("Delivered hinted shopping list " + hint.Email + " to " + hint.IntendedServer)
+	}
+}
+
+// deliverHint sends hint to its intended server. A server without the list cannot apply a
+// delta and answers 412, it then gets the delta joined with the list stored here.
+func (s *Server) deliverHint(hint storage.Hint) error {
+	status, err := s.transport.PutList(hint.IntendedServer, &message.PutList{Email: hint.Email, List: hint.List, Delta: hint.IsDelta})
+	if err != nil {
+		return err
+	}
+	if status == http.StatusPreconditionFailed && hint.IsDelta {
+		stored, err := s.store.Get(string(s.hasher().Hash([]byte(hint.Email))))
+		if err != nil {
+			return fmt.Errorf("the full shopping list is required and not stored here: %w", err)
+		}
+		stored.List.Join(crdt.FromGOB64(hint.List))
+		return s.sendShoppingList(hint.IntendedServer, hint.Email, stored.List.ToGOB64(), false)
+	}
+	if status != http.StatusOK {
+		return message.UnexpectedStatus(hint.IntendedServer, status)
+	}
+	return nil
+}
+
+// isMember reports whether the server at address belongs to the ring and has not left it.
+func (s *Server) isMember(address string) bool {
+	for _, member := range s.members.Membership().Members {
+		if member.Address == address {
+			return member.Status != message.Left
+		}
+	}
+	return false
+}
+
+// newSyncList ships a delta of stored when the peer's context is known and the full state otherwise,
+// together with the full causal context of this server so the peer can answer with a delta.
+func newSyncList(stored storage.Record, peerContext *causalcontext.CausalContext) message.SyncList {
+	list := stored.List
+	if peerContext != nil {
+		list = stored.List.Delta(peerContext)
+	}
+	return message.SyncList{
+		Email:     stored.Email,
+		EmailHash: message.Hash(stored.EmailHash),
+		List:      list.ToGOB64(),
+		Context:   stored.List.Cc.ToGOB64(),
+		Delta:     peerContext != nil,
+	}
+}
+
+// decodeContext decodes a GOB64 causal context, an empty or invalid one is unknown.
+func decodeContext(encoded string) *causalcontext.CausalContext {
+	if encoded == "" {
+		return nil
+	}
+	context, err := causalcontext.FromGOB64(encoded)
+	if err != nil {
+		return nil
+	}
+	return context
+}
+
+func (s *Server) mergeSyncLists(lists []message.SyncList) {
+	for _, synced := range lists {
+		err := s.mergeShoppingList(synced.Email, string(synced.EmailHash), crdt.FromGOB64(synced.List), synced.Delta)
+		if err != nil {
+			fmt.Println("Error merging shopping list:", err)
+		}
+	}
+}
+
+// Depth of the Merkle trees used for anti-entropy, they have 2^merkleDepth leaves
+const merkleDepth = 8
+
+// hashRange is the range of email hashes (start, end]
+type hashRange struct {
+	start string
+	end   string
+}
+
+// treeFor returns the Merkle tree of the shopping lists in (startHash, endHash],
+// building it from the store the first time the range is synchronized.
+func (s *Server) treeFor(startHash, endHash string) (*merkle.Tree, error) {
+	s.treesLock.Lock()
+	defer s.treesLock.Unlock()
+	key := hashRange{start: startHash, end: endHash}
+	if tree, exists := s.trees[key]; exists {
+		return tree, nil
+	}
+	lists, err := s.store.Range(startHash, endHash)
+	if err != nil {
+		return nil, err
+	}
+	tree := merkle.NewTree(merkleDepth, startHash, endHash)
+	for _, stored := range lists {
+		tree.Put(stored.EmailHash, stored.List.Digest())
+	}
+	s.trees[key] = tree
+	return tree, nil
+}
+
+// evictTrees drops the Merkle trees of the ranges that are not in held, the ring moved them
+// away from this server or split them. A range that comes back gets a tree built from the store.
+func (s *Server) evictTrees(held map[hashRange]bool) {
+	s.treesLock.Lock()
+	defer s.treesLock.Unlock()
+	for key := range s.trees {
+		if !held[key] {
+			delete(s.trees, key)
+		}
+	}
+}
+
+// updateTrees records the new digest of a shopping list in every tree whose range holds it.
+func (s *Server) updateTrees(emailHash string, digest []byte) {
+	s.treesLock.Lock()
+	defer s.treesLock.Unlock()
+	for key, tree := range s.trees {
+		if storage.InRange(emailHash, key.start, key.end) {
+			tree.Put(emailHash, digest)
+		}
+	}
+}
+
+func (server *Server) Sync() {
+	nodes, epoch := server.topology()
+	for _, node := range nodes {
+		if len(node.frontNodes) == 0 {
+			continue
+		}
+		keyRange := message.KeyRange{Start: node.rangeStart, End: node.hashId}
+		tree, err := server.treeFor(string(keyRange.Start), string(keyRange.End))
+		if err != nil {
+			fmt.Println("Error building Merkle tree:", err)
+			continue
+		}
+		for _, frontNeighbor := range node.frontNodes {
+			leaves, err := server.differingLeaves(frontNeighbor.server, epoch, keyRange, tree)
+			if err != nil {
+				fmt.Println("Error comparing Merkle trees:", err)
+				continue
+			}
+			if len(leaves) == 0 {
+				continue
+			}
+			fmt.Printf("Synchronizing %d differing leaves with the front neighbor with port %s\n", len(leaves), frontNeighbor.server)
+			err = server.syncLeaves(frontNeighbor.server, epoch, keyRange, tree, leaves)
+			if err != nil {
+				fmt.Println("Error synchronizing shopping lists:", err)
+			}
+		}
+	}
+}
+
+// differingLeaves walks down the Merkle trees of this server and of peer from the root,
+// descending only into the nodes whose hashes differ, and returns the leaves that differ.
+func (server *Server) differingLeaves(peer string, epoch uint64, keyRange message.KeyRange, tree *merkle.Tree) ([]int, error) {
+	return tree.DifferingLeaves(func(level []int) ([]int, error) {
+		request := &message.SyncTree{Epoch: epoch, Range: keyRange}
+		for _, index := range level {
+			request.Nodes = append(request.Nodes, message.TreeNode{Index: index, Hash: tree.Hash(index)})
+		}
+		response, status, err := server.transport.SyncTree(peer, request)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, message.UnexpectedStatus(peer, status)
+		}
+		return response.Differing, nil
+	})
+}
+
+// syncLeaves exchanges the shopping lists under the differing leaves with peer. The peer sends
+// back the lists it holds differently and asks for the ones it misses, and both sides
+// only ship the mutations the other side has not seen.
+func (server *Server) syncLeaves(peer string, epoch uint64, keyRange message.KeyRange, tree *merkle.Tree, leaves []int) error {
+	request := &message.SyncKeys{Epoch: epoch, Range: keyRange, Leaves: leaves}
+	for _, leaf := range leaves {
+		for emailHash, digest := range tree.Digests(leaf) {
+			stored, err := server.store.Get(emailHash)
+			if err != nil {
+				continue
+			}
+			request.Digests = append(request.Digests, message.ListDigest{EmailHash: message.Hash(emailHash), Digest: digest, Context: stored.List.Cc.ToGOB64()})
+		}
+	}
+
+	response, status, err := server.transport.SyncKeys(peer, request)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return message.UnexpectedStatus(peer, status)
+	}
+
+	// the lists the peer holds differently
+	server.mergeSyncLists(response.Lists)
+
+	// the lists the peer is missing or holds differently
+	lists := &message.SyncLists{}
+	for _, wanted := range response.Wanted {
+		stored, err := server.store.Get(string(wanted.EmailHash))
+		if err != nil {
+			continue
+		}
+		lists.Lists = append(lists.Lists, newSyncList(stored, decodeContext(wanted.Context)))
+	}
+	if len(lists.Lists) == 0 {
+		return nil
+	}
+	status, err = server.transport.SyncLists(peer, lists)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return message.UnexpectedStatus(peer, status)
+	}
+	return nil
+}
+
+func (s *Server) HandleSyncTree(w http.ResponseWriter, r *http.Request) {
+	var request message.SyncTree
+	err := message.ReadRequest(r, &request)
+	if err != nil {
+		message.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	response, err := s.SyncTree(&request)
+	if err != nil {
+		message.Fail(w, err)
+		return
+	}
+	err = message.Write(w, http.StatusOK, response)
+	if err != nil {
+		fmt.Println("Error writing response:", err)
+	}
+}
+
+// SyncTree compares the Merkle tree node hashes of a peer with the ones of this
+// server for the same range and answers with the indexes of the nodes that differ.
+func (s *Server) SyncTree(request *message.SyncTree) (*message.SyncTreeResponse, error) {
+	// the range of a sender with an older ring may no longer be shared with this server
+	err := s.checkEpoch(request.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := s.treeFor(string(request.Range.Start), string(request.Range.End))
+	if err != nil {
+		return nil, message.Errorf(http.StatusInternalServerError, "Error building Merkle tree")
+	}
+
+	response := &message.SyncTreeResponse{}
+	for _, node := range request.Nodes {
+		if !tree.Valid(node.Index) {
+			return nil, message.Errorf(http.StatusBadRequest, "Invalid tree node")
+		}
+		if !tree.Equal(node.Index, node.Hash) {
+			response.Differing = append(response.Differing, node.Index)
+		}
+	}
+	return response, nil
+}
+
+func (s *Server) HandleSyncKeys(w http.ResponseWriter, r *http.Request) {
+	var request message.SyncKeys
+	err := message.ReadRequest(r, &request)
+	if err != nil {
+		message.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	response, err := s.SyncKeys(&request)
+	if err != nil {
+		message.Fail(w, err)
+		return
+	}
+	err = message.Write(w, http.StatusOK, response)
+	if err != nil {
+		fmt.Println("Error writing response:", err)
+	}
+}
+
+// SyncKeys receives the digests of the shopping lists under the leaves that differ,
+// answers with the lists this server holds differently and with the ones it wants back.
+func (s *Server) SyncKeys(request *message.SyncKeys) (*message.SyncKeysResponse, error) {
+	err := s.checkEpoch(request.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := s.treeFor(string(request.Range.Start), string(request.Range.End))
+	if err != nil {
+		return nil, message.Errorf(http.StatusInternalServerError, "Error building Merkle tree")
+	}
+
+	// digests and causal contexts of the sender
+	senderDigests := make(map[string][]byte)
+	senderContexts := make(map[string]*causalcontext.CausalContext)
+	for _, digest := range request.Digests {
+		senderDigests[string(digest.EmailHash)] = digest.Digest
+		senderContexts[string(digest.EmailHash)] = decodeContext(digest.Context)
+	}
+
+	// digests of this server under the same leaves
+	ownDigests := make(map[string][]byte)
+	for _, leaf := range request.Leaves {
+		if !tree.Valid(leaf) || !tree.IsLeaf(leaf) {
+			return nil, message.Errorf(http.StatusBadRequest, "Invalid tree leaf")
+		}
+		for emailHash, digest := range tree.Digests(leaf) {
+			ownDigests[emailHash] = digest
+		}
+	}
+
+	response := &message.SyncKeysResponse{}
+	for emailHash, digest := range ownDigests {
+		if bytes.Equal(senderDigests[emailHash], digest) {
+			continue
+		}
+		stored, err := s.store.Get(emailHash)
+		if err != nil {
+			continue
+		}
+		response.Lists = append(response.Lists, newSyncList(stored, senderContexts[emailHash]))
+	}
+
+	for emailHash, digest := range senderDigests {
+		ownDigest, exists := ownDigests[emailHash]
+		if exists && bytes.Equal(ownDigest, digest) {
+			continue
+		}
+		// without a context the sender ships the full list
+		wanted := message.WantedList{EmailHash: message.Hash(emailHash)}
+		if exists {
+			stored, err := s.store.Get(emailHash)
+			if err == nil {
+				wanted.Context = stored.List.Cc.ToGOB64()
+			}
+		}
+		response.Wanted = append(response.Wanted, wanted)
+	}
+	return response, nil
+}
+
+func (s *Server) HandleSyncShoppingList(w http.ResponseWriter, r *http.Request) {
+	var lists message.SyncLists
+	err := message.ReadRequest(r, &lists)
+	if err != nil {
+		message.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+	s.SyncLists(&lists)
+	w.WriteHeader(http.StatusOK)
+}
+
+// SyncLists merges the shopping lists a peer sends during anti-entropy.
+func (s *Server) SyncLists(lists *message.SyncLists) error {
+	s.mergeSyncLists(lists.Lists)
+	return nil
+}
+
+func main() {
+	transportName := flag.String("transport", "http", "transport used to talk to the other nodes, http or grpc")
+	backend := flag.String("store", "sqlite", "storage backend of the shopping lists, sqlite, memory or file")
+	loadBalancers := flag.String("lb", "localhost:8080", "comma separated addresses of the load balancers to connect through")
+	zone := flag.String("zone", "", "host, rack or data center of the server, replicas are spread over distinct zones")
+	weight := flag.Int("weight", 1, "capacity of the server relative to the others, the ring gives it virtual nodes in proportion")
+	flag.Parse()
+	if flag.NArg() < 2 {
+		fmt.Println("Usage: ./server [-transport http|grpc] [-store sqlite|memory|file] [-lb addresses] <port> <name>")
+		return
+	}
+	transport, err := rpc.NewTransport(*transportName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	store, err := storage.Open(*backend, "../node_storage/"+flag.Arg(1))
+	if err != nil {
+		fmt.Println("Error opening storage:", err)
+		os.Exit(1)
 	}
 	// create an HTTP server with the specified port
-	server := NewServer(os.Args[1], os.Args[2])
+	server := NewServer(flag.Arg(0), flag.Arg(1), *zone, *weight, strings.Split(*loadBalancers, ","), transport, store)
 	http.HandleFunc("/putListServer", server.HandleShoppingListPut)
 	http.HandleFunc("/getListServer/", server.HandleShoppingListGet)
-	http.HandleFunc("/shareNeighboursInformation", server.HandleNeighboursInformation)
-	http.HandleFunc("/requestKeys", server.HandleRequestKeys)
+	http.HandleFunc("/gossip", server.HandleGossip)
+	http.HandleFunc("/leave", server.HandleLeave)
+	http.HandleFunc("/weight", server.HandleSetWeight)
 	http.HandleFunc("/sendMeKeys", server.HandleSendMeKeys)
+	http.HandleFunc("/sendKeys", server.HandleSendKeys)
+	http.HandleFunc("/fetchKeys", server.HandleFetchKeys)
+	http.HandleFunc("/syncTree", server.HandleSyncTree)
+	http.HandleFunc("/syncKeys", server.HandleSyncKeys)
 	http.HandleFunc("/syncShoppingList", server.HandleSyncShoppingList)
+	http.HandleFunc("/health", server.HandleHealth)
+	// the same requests over gRPC, listening before joining the ring
+	rpcListener, err := rpc.Listen(":" + server.port)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		log.Fatal(rpc.NewStorageServer(server).Serve(rpcListener))
+	}()
 	// sync the shopping lists
 	go func() {
 		for {
@@ -663,6 +1501,20 @@ func main() {
 			server.Sync()
 		}
 	}()
+	// spread the membership of the ring
+	go func() {
+		for {
+			time.Sleep(gossipInterval)
+			server.gossip()
+		}
+	}()
+	// hand hinted shopping lists back to their owners
+	go func() {
+		for {
+			time.Sleep(time.Second * 5)
+			server.deliverHints()
+		}
+	}()
 	go server.Run()
 	fmt.Println("listening on port", server.port)
 	log.Fatal(http.ListenAndServe(":"+server.port, nil))
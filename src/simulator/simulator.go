@@ -0,0 +1,261 @@
+package main
+
+import (
+	"CloudShoppingList/consistent_hashing"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// simNode is a node of the simulated ring, its id is also its server.
+type simNode struct {
+	id     string
+	weight int
+}
+
+// scenario is the ring after a change of the simulated nodes, with the keys each node owns.
+type scenario struct {
+	name  string
+	nodes []simNode
+	owned map[string]int
+	// fraction of the keys whose owner is not the one of the base ring
+	moved float64
+}
+
+// buildPartitioner places nodes with a new partitioner called name, a ring by default.
+func buildPartitioner(name string, config consistent.RingConfig, nodes []simNode) (consistent.Partitioner, error) {
+	partitioner, err := consistent.NewPartitioner(name, config)
+	if err != nil {
+		return nil, err
+	}
+	var members []consistent.Member
+	for _, node := range nodes {
+		members = append(members, consistent.Member{ID: node.id, Server: node.id, VirtualNodes: config.VirtualNodesFor(node.weight)})
+	}
+	partitioner.Restore(members, 1)
+	return partitioner, nil
+}
+
+// email is the i-th synthetic email hashed by the simulation.
+func email(i int) string {
+	return "user" + strconv.Itoa(i) + "@example.com"
+}
+
+// simulate hashes keys synthetic emails on the ring of nodes, or with the partitioner called
+// name, then with added and without removed nodes, and counts the keys every node owns and
+// the keys that move.
+func simulate(name string, config consistent.RingConfig, nodes []simNode, added, removed int, keys int) ([]scenario, error) {
+	base := scenario{name: "base", nodes: nodes, owned: make(map[string]int)}
+	partitioner, err := buildPartitioner(name, config, nodes)
+	if err != nil {
+		return nil, err
+	}
+	owners := make([]string, keys)
+	for i := range owners {
+		owner, err := partitioner.Get(email(i))
+		if err != nil {
+			return nil, err
+		}
+		owners[i] = owner
+		base.owned[owner]++
+	}
+	scenarios := []scenario{base}
+
+	if added > 0 {
+		grown := append([]simNode{}, nodes...)
+		for i := 0; i < added; i++ {
+			grown = append(grown, simNode{id: fmt.Sprintf("node%02d", len(nodes)+i), weight: 1})
+		}
+		scenarios = append(scenarios, scenario{name: fmt.Sprintf("add %d", added), nodes: grown})
+	}
+	if removed > 0 {
+		if removed >= len(nodes) {
+			return nil, fmt.Errorf("cannot remove %d of %d nodes", removed, len(nodes))
+		}
+		scenarios = append(scenarios, scenario{name: fmt.Sprintf("remove %d", removed), nodes: nodes[removed:]})
+	}
+
+	for s := 1; s < len(scenarios); s++ {
+		changed := &scenarios[s]
+		changed.owned = make(map[string]int)
+		partitioner, err := buildPartitioner(name, config, changed.nodes)
+		if err != nil {
+			return nil, err
+		}
+		moved := 0
+		for i, before := range owners {
+			owner, err := partitioner.Get(email(i))
+			if err != nil {
+				return nil, err
+			}
+			changed.owned[owner]++
+			if owner != before {
+				moved++
+			}
+		}
+		changed.moved = float64(moved) / float64(keys)
+	}
+	return scenarios, nil
+}
+
+// expectedShare is the share of the keys a node of weight gets when the keys are spread in
+// proportion to the weights of nodes.
+func expectedShare(nodes []simNode, weight int) float64 {
+	total := 0
+	for _, node := range nodes {
+		total += node.weight
+	}
+	return float64(weight) / float64(total)
+}
+
+// deviation is the standard deviation of the keys of the nodes of s from their expected keys,
+// as a fraction of the expected keys: 0 when every node owns exactly its share.
+func (s scenario) deviation(keys int) float64 {
+	sum := 0.0
+	for _, node := range s.nodes {
+		expected := expectedShare(s.nodes, node.weight) * float64(keys)
+		relative := (float64(s.owned[node.id]) - expected) / expected
+		sum += relative * relative
+	}
+	return math.Sqrt(sum / float64(len(s.nodes)))
+}
+
+func printText(name string, config consistent.RingConfig, scenarios []scenario, keys int) {
+	fmt.Printf("%s, %d virtual nodes per node of weight 1, %d keys hashed with %s\n", name, config.VirtualNodes, keys, config.Hasher.Name())
+	for _, s := range scenarios {
+		fmt.Printf("  %s: %d nodes, standard deviation %.2f%%", s.name, len(s.nodes), 100*s.deviation(keys))
+		if s.name != "base" {
+			fmt.Printf(", %.2f%% of the keys moved", 100*s.moved)
+		}
+		fmt.Println()
+		for _, node := range s.nodes {
+			share := float64(s.owned[node.id]) / float64(keys)
+			fmt.Printf("    %-8s weight %-3d %9d keys %6.2f%% (expected %.2f%%)\n", node.id, node.weight, s.owned[node.id], 100*share, 100*expectedShare(s.nodes, node.weight))
+		}
+	}
+}
+
+// csvHeader names the columns of the rows of writeCSV, one row per node of every scenario.
+var csvHeader = []string{"partitioner", "vnodes", "hash", "scenario", "nodes", "node", "weight", "virtual_nodes", "keys", "share", "expected_share", "stddev", "moved"}
+
+func writeCSV(writer *csv.Writer, name string, config consistent.RingConfig, scenarios []scenario, keys int) error {
+	for _, s := range scenarios {
+		for _, node := range s.nodes {
+			err := writer.Write([]string{
+				name,
+				strconv.Itoa(config.VirtualNodes),
+				config.Hasher.Name(),
+				s.name,
+				strconv.Itoa(len(s.nodes)),
+				node.id,
+				strconv.Itoa(node.weight),
+				strconv.Itoa(config.VirtualNodesFor(node.weight)),
+				strconv.Itoa(s.owned[node.id]),
+				strconv.FormatFloat(float64(s.owned[node.id])/float64(keys), 'f', 6, 64),
+				strconv.FormatFloat(expectedShare(s.nodes, node.weight), 'f', 6, 64),
+				strconv.FormatFloat(s.deviation(keys), 'f', 6, 64),
+				strconv.FormatFloat(s.moved, 'f', 6, 64),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// parseInts reads a comma separated list of positive numbers.
+func parseInts(list, name string) ([]int, error) {
+	var numbers []int
+	for _, field := range strings.Split(list, ",") {
+		if field == "" {
+			continue
+		}
+		number, err := strconv.Atoi(field)
+		if err != nil || number < 1 {
+			return nil, fmt.Errorf("%s %q is not a positive number", name, field)
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, nil
+}
+
+func main() {
+	nodeCount := flag.Int("nodes", 10, "number of nodes in the ring")
+	virtualNodes := flag.String("vnodes", strconv.Itoa(consistent.DefaultRingConfig().VirtualNodes), "comma separated numbers of virtual nodes of a node of weight 1 to simulate, one ring each")
+	weightList := flag.String("weights", "", "comma separated weights of the first nodes, the others have weight 1")
+	keys := flag.Int("keys", 1000000, "number of synthetic emails hashed on the ring")
+	added := flag.Int("add", 1, "number of nodes of weight 1 added to count the keys that move")
+	removed := flag.Int("remove", 1, "number of nodes removed, the first ones, to count the keys that move")
+	hash := flag.String("hash", "sha256", "hash function placing keys on the ring, sha256, xxhash or murmur3")
+	partitionerName := flag.String("partitioner", "ring", "how keys are assigned to nodes, ring, rendezvous, jump or bounded")
+	format := flag.String("format", "text", "output format, text or csv")
+	flag.Parse()
+
+	weights, err := parseInts(*weightList, "weight")
+	if err != nil {
+		log.Fatal(err)
+	}
+	vnodeCounts, err := parseInts(*virtualNodes, "virtual node count")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(vnodeCounts) == 0 {
+		log.Fatal("no virtual node count to simulate")
+	}
+	if *nodeCount < len(weights) {
+		*nodeCount = len(weights)
+	}
+	if *nodeCount < 1 || *keys < 1 || *added < 0 || *removed < 0 {
+		log.Fatal("the ring needs nodes and keys, and nodes cannot be added or removed a negative number of times")
+	}
+	if *format != "text" && *format != "csv" {
+		log.Fatalf("unknown format %q", *format)
+	}
+	hasher, err := consistent.NewHasher(*hash)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := consistent.NewPartitioner(*partitionerName, consistent.DefaultRingConfig()); err != nil {
+		log.Fatal(err)
+	}
+
+	var nodes []simNode
+	for i := 0; i < *nodeCount; i++ {
+		node := simNode{id: fmt.Sprintf("node%02d", i), weight: 1}
+		if i < len(weights) {
+			node.weight = weights[i]
+		}
+		nodes = append(nodes, node)
+	}
+
+	writer := csv.NewWriter(os.Stdout)
+	if *format == "csv" {
+		err = writer.Write(csvHeader)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, count := range vnodeCounts {
+		// only owners are counted, so no replicas are needed
+		config := consistent.RingConfig{VirtualNodes: count, ReplicationFactor: 0, Hasher: hasher}
+		scenarios, err := simulate(*partitionerName, config, nodes, *added, *removed, *keys)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *format == "csv" {
+			err = writeCSV(writer, *partitionerName, config, scenarios, *keys)
+			if err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			printText(*partitionerName, config, scenarios, *keys)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"CloudShoppingList/crdt"
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// operations of the log
+const (
+	opPut        = "put"
+	opDelete     = "delete"
+	opHint       = "hint"
+	opRemoveHint = "remove_hint"
+)
+
+// logEntry is one line of the log. Puts and hints carry the whole state after the change,
+// so replaying the log only has to keep the last entry of every key.
+type logEntry struct {
+	Op             string `json:"op"`
+	Email          string `json:"email,omitempty"`
+	EmailHash      string `json:"email_hash,omitempty"`
+	IntendedServer string `json:"intended_server,omitempty"`
+	List           string `json:"list,omitempty"`
+	IsDelta        bool   `json:"is_delta,omitempty"`
+}
+
+// Size of the log past which it is compacted, once it also doubled since the last compaction
+const compactionSize = 4 << 20
+
+// File appends every change to a log file of JSON lines and serves reads from memory.
+// The log is replayed when the store is opened. It is rewritten from the memory, one
+// entry per list and hint, once it grows past compactAt and when the store is closed.
+type File struct {
+	sync.Mutex
+	memory *Memory
+	path   string
+	file   *os.File
+	// size of the log, and its size right after the last compaction
+	size      int64
+	compacted int64
+	compactAt int64
+}
+
+func OpenFile(path string) (*File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{memory: NewMemory(), path: path, file: file, compactAt: compactionSize}
+	err = f.replay()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f.compacted = f.size
+	return f, nil
+}
+
+// replay rebuilds the memory from the log, dropping a last line cut short by a crash.
+func (f *File) replay() error {
+	reader := bufio.NewReader(f.file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var entry logEntry
+		if json.Unmarshal(line, &entry) != nil {
+			break
+		}
+		f.apply(entry)
+		offset += int64(len(line))
+	}
+	err := f.file.Truncate(offset)
+	if err != nil {
+		return err
+	}
+	f.size = offset
+	_, err = f.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+func (f *File) apply(entry logEntry) {
+	emailHash, _ := hex.DecodeString(entry.EmailHash)
+	switch entry.Op {
+	case opPut:
+		f.memory.lists[string(emailHash)] = memoryList{email: entry.Email, list: entry.List}
+	case opDelete:
+		delete(f.memory.lists, string(emailHash))
+	case opHint:
+		f.memory.hints[hintKey{email: entry.Email, intendedServer: entry.IntendedServer}] = Hint{Email: entry.Email, IntendedServer: entry.IntendedServer, List: entry.List, IsDelta: entry.IsDelta}
+	case opRemoveHint:
+		delete(f.memory.hints, hintKey{email: entry.Email, intendedServer: entry.IntendedServer})
+	}
+}
+
+func (f *File) append(entry logEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	written, err := f.file.Write(append(line, '\n'))
+	f.size += int64(written)
+	if err != nil {
+		return err
+	}
+	if f.size >= f.compactAt && f.size >= 2*f.compacted {
+		return f.compact()
+	}
+	return nil
+}
+
+// compact replaces the log with the entries of the lists and hints in memory. The new log is
+// written aside and renamed over the old one, so a crash leaves one of them whole. The
+// caller holds the lock of f.
+func (f *File) compact() error {
+	f.memory.Lock()
+	entries := make([]logEntry, 0, len(f.memory.lists)+len(f.memory.hints))
+	for emailHash, stored := range f.memory.lists {
+		entries = append(entries, logEntry{Op: opPut, Email: stored.email, EmailHash: hex.EncodeToString([]byte(emailHash)), List: stored.list})
+	}
+	for _, hint := range f.memory.hints {
+		entries = append(entries, logEntry{Op: opHint, Email: hint.Email, IntendedServer: hint.IntendedServer, List: hint.List, IsDelta: hint.IsDelta})
+	}
+	f.memory.Unlock()
+
+	compactPath := f.path + ".compact"
+	file, err := os.OpenFile(compactPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	var size int64
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err == nil {
+			var written int
+			written, err = writer.Write(append(line, '\n'))
+			size += int64(written)
+		}
+		if err != nil {
+			file.Close()
+			os.Remove(compactPath)
+			return err
+		}
+	}
+	err = writer.Flush()
+	if err == nil {
+		err = file.Sync()
+	}
+	if err == nil {
+		err = os.Rename(compactPath, f.path)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(compactPath)
+		return err
+	}
+	f.file.Close()
+	f.file = file
+	f.size = size
+	f.compacted = size
+	return nil
+}
+
+func (f *File) Get(emailHash string) (Record, error) {
+	return f.memory.Get(emailHash)
+}
+
+func (f *File) Merge(email string, emailHash string, list *crdt.List, isDelta bool) (*crdt.List, error) {
+	f.Lock()
+	defer f.Unlock()
+	merged, err := f.memory.Merge(email, emailHash, list, isDelta)
+	if err != nil {
+		return nil, err
+	}
+	stored := f.memory.lists[emailHash]
+	return merged, f.append(logEntry{Op: opPut, Email: stored.email, EmailHash: hex.EncodeToString([]byte(emailHash)), List: stored.list})
+}
+
+func (f *File) Range(startHash, endHash string) ([]Record, error) {
+	return f.memory.Range(startHash, endHash)
+}
+
+func (f *File) Scan(startHash, endHash string, limit int) ([]Record, error) {
+	return f.memory.Scan(startHash, endHash, limit)
+}
+
+func (f *File) Delete(emailHash string) error {
+	f.Lock()
+	defer f.Unlock()
+	err := f.memory.Delete(emailHash)
+	if err != nil {
+		return err
+	}
+	return f.append(logEntry{Op: opDelete, EmailHash: hex.EncodeToString([]byte(emailHash))})
+}
+
+func (f *File) Iterate(fn func(Record) bool) error {
+	return f.memory.Iterate(fn)
+}
+
+func (f *File) AddHint(hint Hint) error {
+	f.Lock()
+	defer f.Unlock()
+	f.memory.Lock()
+	hint = f.memory.addHint(hint)
+	f.memory.Unlock()
+	return f.append(logEntry{Op: opHint, Email: hint.Email, IntendedServer: hint.IntendedServer, List: hint.List, IsDelta: hint.IsDelta})
+}
+
+func (f *File) Hints() ([]Hint, error) {
+	return f.memory.Hints()
+}
+
+func (f *File) RemoveHint(hint Hint) error {
+	f.Lock()
+	defer f.Unlock()
+	f.memory.Lock()
+	removed := f.memory.removeHint(hint)
+	f.memory.Unlock()
+	if !removed {
+		return nil
+	}
+	return f.append(logEntry{Op: opRemoveHint, Email: hint.Email, IntendedServer: hint.IntendedServer})
+}
+
+// Close compacts the log, so the next open replays one entry per list and hint.
+func (f *File) Close() error {
+	f.Lock()
+	defer f.Unlock()
+	err := f.compact()
+	if err != nil {
+		f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
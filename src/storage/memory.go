@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"CloudShoppingList/crdt"
+	"sort"
+	"sync"
+)
+
+type memoryList struct {
+	email string
+	list  string
+}
+
+type hintKey struct {
+	email          string
+	intendedServer string
+}
+
+// Memory keeps everything in memory, it is lost when the server stops.
+type Memory struct {
+	sync.Mutex
+	// lists are kept GOB64 encoded so callers never share them
+	lists map[string]memoryList
+	hints map[hintKey]Hint
+}
+
+func NewMemory() *Memory {
+	return &Memory{lists: make(map[string]memoryList), hints: make(map[hintKey]Hint)}
+}
+
+func (m *Memory) Get(emailHash string) (Record, error) {
+	m.Lock()
+	defer m.Unlock()
+	stored, exists := m.lists[emailHash]
+	if !exists {
+		return Record{}, ErrNotFound
+	}
+	return Record{Email: stored.email, EmailHash: emailHash, List: crdt.FromGOB64(stored.list)}, nil
+}
+
+func (m *Memory) Merge(email string, emailHash string, list *crdt.List, isDelta bool) (*crdt.List, error) {
+	m.Lock()
+	defer m.Unlock()
+	return m.merge(email, emailHash, list, isDelta)
+}
+
+func (m *Memory) merge(email string, emailHash string, list *crdt.List, isDelta bool) (*crdt.List, error) {
+	stored, exists := m.lists[emailHash]
+	if !exists {
+		if isDelta {
+			return nil, ErrMissingState
+		}
+		m.lists[emailHash] = memoryList{email: email, list: list.ToGOB64()}
+		return crdt.FromGOB64(m.lists[emailHash].list), nil
+	}
+	merged := crdt.FromGOB64(stored.list)
+	merged.Join(list)
+	stored.list = merged.ToGOB64()
+	m.lists[emailHash] = stored
+	return merged, nil
+}
+
+func (m *Memory) Range(startHash, endHash string) ([]Record, error) {
+	m.Lock()
+	defer m.Unlock()
+	var records []Record
+	for emailHash, stored := range m.lists {
+		if InRange(emailHash, startHash, endHash) {
+			records = append(records, Record{Email: stored.email, EmailHash: emailHash, List: crdt.FromGOB64(stored.list)})
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].EmailHash < records[j].EmailHash
+	})
+	return records, nil
+}
+
+func (m *Memory) Scan(startHash, endHash string, limit int) ([]Record, error) {
+	records, err := m.Range(startHash, endHash)
+	if err != nil {
+		return nil, err
+	}
+	records = ringOrder(records, startHash)
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+func (m *Memory) Delete(emailHash string) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.lists, emailHash)
+	return nil
+}
+
+func (m *Memory) Iterate(fn func(Record) bool) error {
+	// iterate over a snapshot so fn can use the store
+	records, err := m.Range("", "")
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if !fn(record) {
+			break
+		}
+	}
+	return nil
+}
+
+func (m *Memory) AddHint(hint Hint) error {
+	m.Lock()
+	defer m.Unlock()
+	m.addHint(hint)
+	return nil
+}
+
+func (m *Memory) addHint(hint Hint) Hint {
+	key := hintKey{email: hint.Email, intendedServer: hint.IntendedServer}
+	if kept, exists := m.hints[key]; exists {
+		hint = joinHint(kept, hint)
+	}
+	m.hints[key] = hint
+	return hint
+}
+
+func (m *Memory) Hints() ([]Hint, error) {
+	m.Lock()
+	defer m.Unlock()
+	var hints []Hint
+	for _, hint := range m.hints {
+		hints = append(hints, hint)
+	}
+	return hints, nil
+}
+
+func (m *Memory) RemoveHint(hint Hint) error {
+	m.Lock()
+	defer m.Unlock()
+	m.removeHint(hint)
+	return nil
+}
+
+func (m *Memory) removeHint(hint Hint) bool {
+	key := hintKey{email: hint.Email, intendedServer: hint.IntendedServer}
+	kept, exists := m.hints[key]
+	if !exists || kept.List != hint.List {
+		return false
+	}
+	delete(m.hints, key)
+	return true
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"CloudShoppingList/crdt"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLite keeps the lists in a SQLite database.
+type SQLite struct {
+	db *sql.DB
+}
+
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("Opened database successfully")
+	// SQLite takes one writer at a time, with a single connection the transactions wait
+	// for each other in the pool instead of failing as busy
+	db.SetMaxOpenConns(1)
+
+	// create tables if not exists
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS shopping_lists (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email TEXT NOT NULL,
+			email_hash TEXT NOT NULL,
+			shopping_list BLOB NOT NULL
+		);
+	`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	err = mergeDuplicates(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	_, err = db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS shopping_lists_email_hash ON shopping_lists (email_hash)")
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// lists kept on behalf of replicas that could not be reached, until they come back
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS hinted_lists (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email TEXT NOT NULL,
+			intended_server TEXT NOT NULL,
+			shopping_list BLOB NOT NULL,
+			is_delta INTEGER NOT NULL
+		);
+	`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLite{db: db}, nil
+}
+
+// queryer is what Get needs, a database or a transaction.
+type queryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func (s *SQLite) Get(emailHash string) (Record, error) {
+	return get(s.db, emailHash)
+}
+
+func get(db queryer, emailHash string) (Record, error) {
+	row := db.QueryRow("SELECT email, shopping_list FROM shopping_lists WHERE email_hash = ?", emailHash)
+	var email string
+	var shoppingList []byte
+	err := row.Scan(&email, &shoppingList)
+	if err == sql.ErrNoRows {
+		return Record{}, ErrNotFound
+	}
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{Email: email, EmailHash: emailHash, List: crdt.FromGOB64(string(shoppingList))}, nil
+}
+
+// Merge reads, joins and writes the list in one transaction, so concurrent merges of the
+// same list never overwrite each other.
+func (s *SQLite) Merge(email string, emailHash string, list *crdt.List, isDelta bool) (*crdt.List, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stored, err := get(tx, emailHash)
+	if err == nil {
+		stored.List.Join(list)
+		_, err = tx.Exec("UPDATE shopping_lists SET shopping_list = ? WHERE email_hash = ?", []byte(stored.List.ToGOB64()), emailHash)
+		if err != nil {
+			return nil, err
+		}
+		return stored.List, tx.Commit()
+	}
+	if err != ErrNotFound {
+		return nil, err
+	}
+	if isDelta {
+		return nil, ErrMissingState
+	}
+	_, err = tx.Exec("INSERT INTO shopping_lists (email, email_hash, shopping_list) VALUES (?, ?, ?)", email, emailHash, []byte(list.ToGOB64()))
+	if err != nil {
+		return nil, err
+	}
+	return list, tx.Commit()
+}
+
+// mergeDuplicates joins the rows stored more than once for the same email hash into one,
+// they were left by concurrent inserts before email_hash was unique.
+func mergeDuplicates(db *sql.DB) error {
+	rows, err := db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists WHERE email_hash IN (SELECT email_hash FROM shopping_lists GROUP BY email_hash HAVING COUNT(*) > 1) ORDER BY id")
+	if err != nil {
+		return err
+	}
+	duplicates, err := scanRecords(rows)
+	if err != nil || len(duplicates) == 0 {
+		return err
+	}
+	merged := make(map[string]Record)
+	for _, record := range duplicates {
+		if kept, exists := merged[record.EmailHash]; exists {
+			kept.List.Join(record.List)
+			continue
+		}
+		merged[record.EmailHash] = record
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for emailHash, record := range merged {
+		_, err = tx.Exec("DELETE FROM shopping_lists WHERE email_hash = ?", emailHash)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("INSERT INTO shopping_lists (email, email_hash, shopping_list) VALUES (?, ?, ?)", record.Email, emailHash, []byte(record.List.ToGOB64()))
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Merged the duplicated rows of %d shopping lists\n", len(merged))
+	return tx.Commit()
+}
+
+func (s *SQLite) Range(startHash, endHash string) ([]Record, error) {
+	var rows *sql.Rows
+	var err error
+	if startHash < endHash {
+		rows, err = s.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists WHERE email_hash > ? AND email_hash <= ? ORDER BY email_hash", startHash, endHash)
+	} else {
+		rows, err = s.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists WHERE email_hash > ? OR email_hash <= ? ORDER BY email_hash", startHash, endHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return scanRecords(rows)
+}
+
+func (s *SQLite) Scan(startHash, endHash string, limit int) ([]Record, error) {
+	if startHash < endHash {
+		rows, err := s.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists WHERE email_hash > ? AND email_hash <= ? ORDER BY email_hash LIMIT ?", startHash, endHash, limit)
+		if err != nil {
+			return nil, err
+		}
+		return scanRecords(rows)
+	}
+	// the range wraps around, the end of the ring comes before its beginning
+	rows, err := s.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists WHERE email_hash > ? ORDER BY email_hash LIMIT ?", startHash, limit)
+	if err != nil {
+		return nil, err
+	}
+	records, err := scanRecords(rows)
+	if err != nil || len(records) == limit {
+		return records, err
+	}
+	rows, err = s.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists WHERE email_hash <= ? ORDER BY email_hash LIMIT ?", endHash, limit-len(records))
+	if err != nil {
+		return nil, err
+	}
+	beginning, err := scanRecords(rows)
+	if err != nil {
+		return nil, err
+	}
+	return append(records, beginning...), nil
+}
+
+func scanRecords(rows *sql.Rows) ([]Record, error) {
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		var email string
+		var emailHash string
+		var shoppingList []byte
+		err := rows.Scan(&email, &emailHash, &shoppingList)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, Record{Email: email, EmailHash: emailHash, List: crdt.FromGOB64(string(shoppingList))})
+	}
+	return records, rows.Err()
+}
+
+func (s *SQLite) Delete(emailHash string) error {
+	_, err := s.db.Exec("DELETE FROM shopping_lists WHERE email_hash = ?", emailHash)
+	return err
+}
+
+func (s *SQLite) Iterate(fn func(Record) bool) error {
+	rows, err := s.db.Query("SELECT email, email_hash, shopping_list FROM shopping_lists ORDER BY email_hash")
+	if err != nil {
+		return err
+	}
+	// read everything first so fn can use the store
+	records, err := scanRecords(rows)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if !fn(record) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *SQLite) AddHint(hint Hint) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow("SELECT shopping_list, is_delta FROM hinted_lists WHERE email = ? AND intended_server = ?", hint.Email, hint.IntendedServer)
+	var kept Hint
+	var hintedList []byte
+	err = row.Scan(&hintedList, &kept.IsDelta)
+	if err == nil {
+		kept.Email, kept.IntendedServer, kept.List = hint.Email, hint.IntendedServer, string(hintedList)
+		hint = joinHint(kept, hint)
+		_, err = tx.Exec("UPDATE hinted_lists SET shopping_list = ?, is_delta = ? WHERE email = ? AND intended_server = ?", []byte(hint.List), hint.IsDelta, hint.Email, hint.IntendedServer)
+	} else if err == sql.ErrNoRows {
+		_, err = tx.Exec("INSERT INTO hinted_lists (email, intended_server, shopping_list, is_delta) VALUES (?, ?, ?, ?)", hint.Email, hint.IntendedServer, []byte(hint.List), hint.IsDelta)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) Hints() ([]Hint, error) {
+	rows, err := s.db.Query("SELECT email, intended_server, shopping_list, is_delta FROM hinted_lists")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var hints []Hint
+	for rows.Next() {
+		var hint Hint
+		var hintedList []byte
+		err = rows.Scan(&hint.Email, &hint.IntendedServer, &hintedList, &hint.IsDelta)
+		if err != nil {
+			return nil, err
+		}
+		hint.List = string(hintedList)
+		hints = append(hints, hint)
+	}
+	return hints, rows.Err()
+}
+
+func (s *SQLite) RemoveHint(hint Hint) error {
+	_, err := s.db.Exec("DELETE FROM hinted_lists WHERE email = ? AND intended_server = ? AND shopping_list = ?", hint.Email, hint.IntendedServer, []byte(hint.List))
+	return err
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,97 @@
+// Package storage keeps the shopping lists of a server, and the lists it holds on behalf of
+// unreachable replicas, behind a Store interface with several backends.
+package storage
+
+import (
+	"CloudShoppingList/crdt"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrNotFound = errors.New("shopping list not found")
+	// ErrMissingState is returned when a delta arrives for a list that is not stored,
+	// since a delta on its own is not a complete shopping list.
+	ErrMissingState = errors.New("no stored shopping list to apply the delta to")
+)
+
+// Record is a stored shopping list. EmailHash is the raw SHA-256 of Email, the position of
+// the list on the ring.
+type Record struct {
+	Email     string
+	EmailHash string
+	List      *crdt.List
+}
+
+// Hint is a shopping list, or a delta of it, kept for IntendedServer while it is unreachable.
+// List is GOB64 encoded.
+type Hint struct {
+	Email          string
+	IntendedServer string
+	List           string
+	IsDelta        bool
+}
+
+type Store interface {
+	// Get returns the list stored for emailHash, or ErrNotFound.
+	Get(emailHash string) (Record, error)
+	// Merge joins list into the list stored for emailHash, storing it when there is none, and
+	// returns the merged list. A delta for a list that is not stored fails with ErrMissingState.
+	Merge(email string, emailHash string, list *crdt.List, isDelta bool) (*crdt.List, error)
+	// Range returns the lists whose hash is in (startHash, endHash], ordered by hash.
+	Range(startHash, endHash string) ([]Record, error)
+	// Scan returns at most limit lists of (startHash, endHash] in ring order, the ones right
+	// after startHash first, so a range can be read in batches resuming after the last hash.
+	Scan(startHash, endHash string, limit int) ([]Record, error)
+	Delete(emailHash string) error
+	// Iterate calls fn with every stored list until it returns false.
+	Iterate(fn func(Record) bool) error
+
+	// AddHint keeps hint, joining it with the hint already kept for the same email and server.
+	AddHint(hint Hint) error
+	Hints() ([]Hint, error)
+	// RemoveHint drops a delivered hint, unless it changed since it was read.
+	RemoveHint(hint Hint) error
+
+	Close() error
+}
+
+// Open opens the store of the given backend, "sqlite", "memory" or "file", at path.
+func Open(backend string, path string) (Store, error) {
+	switch backend {
+	case "sqlite":
+		return OpenSQLite(path + ".db")
+	case "memory":
+		return NewMemory(), nil
+	case "file":
+		return OpenFile(path + ".log")
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", backend)
+}
+
+// InRange tells whether emailHash is in (startHash, endHash], wrapping around the ring when startHash >= endHash.
+func InRange(emailHash, startHash, endHash string) bool {
+	if startHash < endHash {
+		return emailHash > startHash && emailHash <= endHash
+	}
+	return emailHash > startHash || emailHash <= endHash
+}
+
+// ringOrder reorders records sorted by hash to start right after startHash, the order
+// of a range that wraps around the ring.
+func ringOrder(records []Record, startHash string) []Record {
+	first := sort.Search(len(records), func(i int) bool {
+		return records[i].EmailHash > startHash
+	})
+	return append(records[first:len(records):len(records)], records[:first]...)
+}
+
+// joinHint joins a hint into the one already kept, a hint joined with a full state is a full state too.
+func joinHint(kept Hint, hint Hint) Hint {
+	list := crdt.FromGOB64(kept.List)
+	list.Join(crdt.FromGOB64(hint.List))
+	kept.List = list.ToGOB64()
+	kept.IsDelta = kept.IsDelta && hint.IsDelta
+	return kept
+}
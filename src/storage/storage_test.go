@@ -0,0 +1,402 @@
+package storage
+
+import (
+	"CloudShoppingList/crdt"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// backends opens an empty store of every backend.
+func backends(t *testing.T) map[string]Store {
+	t.Helper()
+	stores := map[string]Store{"memory": NewMemory()}
+	for _, backend := range []string{"sqlite", "file"} {
+		store, err := Open(backend, filepath.Join(t.TempDir(), "store"))
+		if err != nil {
+			t.Fatalf("opening %s store: %v", backend, err)
+		}
+		stores[backend] = store
+	}
+	for _, store := range stores {
+		t.Cleanup(func() { store.Close() })
+	}
+	return stores
+}
+
+func values(list *crdt.List) map[string]int {
+	result := make(map[string]int)
+	for key, dotStore := range list.Data {
+		result[key] = dotStore.Value()
+	}
+	return result
+}
+
+func hashes(records []Record) []string {
+	var result []string
+	for _, record := range records {
+		result = append(result, record.EmailHash)
+	}
+	return result
+}
+
+func putItem(t *testing.T, store Store, emailHash string, item string) {
+	t.Helper()
+	list := crdt.NewList(item)
+	list.Increment(item)
+	_, err := store.Merge(emailHash+"@mail", emailHash, list, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeJoinsStoredList(t *testing.T) {
+	for name, store := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			a := crdt.NewList("a")
+			a.Increment("milk")
+			_, err := store.Merge("a@mail", "h1", a, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := crdt.NewList("b")
+			b.Increment("milk")
+			b.Increment("eggs")
+			merged, err := store.Merge("a@mail", "h1", b, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]int{"milk": 2, "eggs": 1}
+			if got := values(merged); !reflect.DeepEqual(got, want) {
+				t.Errorf("merged: got %v, want %v", got, want)
+			}
+
+			stored, err := store.Get("h1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Email != "a@mail" || stored.EmailHash != "h1" {
+				t.Errorf("got record %s %s", stored.Email, stored.EmailHash)
+			}
+			if got := values(stored.List); !reflect.DeepEqual(got, want) {
+				t.Errorf("stored: got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDeltaNeedsStoredList(t *testing.T) {
+	for name, store := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			delta := crdt.NewList("a")
+			delta.Increment("milk")
+			_, err := store.Merge("a@mail", "h1", delta, true)
+			if err != ErrMissingState {
+				t.Fatalf("got %v, want ErrMissingState", err)
+			}
+			_, err = store.Get("h1")
+			if err != ErrNotFound {
+				t.Errorf("got %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestRangeIsOrderedAndWrapsAround(t *testing.T) {
+	for name, store := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, emailHash := range []string{"d", "b", "a", "c", "e"} {
+				putItem(t, store, emailHash, "milk")
+			}
+
+			records, err := store.Range("a", "c")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := hashes(records), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("(a, c]: got %v, want %v", got, want)
+			}
+
+			records, err = store.Range("d", "b")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := hashes(records), []string{"a", "b", "e"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("(d, b]: got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestScanReadsRangesInBatches(t *testing.T) {
+	for name, store := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, emailHash := range []string{"d", "b", "a", "c", "e"} {
+				putItem(t, store, emailHash, "milk")
+			}
+
+			// (c, b] wraps around, resuming after the last hash of every batch
+			var seen []string
+			after := "c"
+			for {
+				records, err := store.Scan(after, "b", 2)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(records) == 0 {
+					break
+				}
+				if len(records) > 2 {
+					t.Fatalf("got %d records, want at most 2", len(records))
+				}
+				seen = append(seen, hashes(records)...)
+				after = records[len(records)-1].EmailHash
+				if after == "b" {
+					break
+				}
+			}
+			if want := []string{"d", "e", "a", "b"}; !reflect.DeepEqual(seen, want) {
+				t.Errorf("got %v, want %v", seen, want)
+			}
+
+			records, err := store.Scan("a", "d", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := hashes(records), []string{"b", "c", "d"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("(a, d]: got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDeleteAndIterate(t *testing.T) {
+	for name, store := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, emailHash := range []string{"a", "b", "c"} {
+				putItem(t, store, emailHash, "milk")
+			}
+			err := store.Delete("b")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var seen []string
+			err = store.Iterate(func(record Record) bool {
+				seen = append(seen, record.EmailHash)
+				return true
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"a", "c"}; !reflect.DeepEqual(seen, want) {
+				t.Errorf("got %v, want %v", seen, want)
+			}
+
+			seen = nil
+			store.Iterate(func(record Record) bool {
+				seen = append(seen, record.EmailHash)
+				return false
+			})
+			if len(seen) != 1 {
+				t.Errorf("iteration went on after returning false: %v", seen)
+			}
+		})
+	}
+}
+
+func TestHintsAreJoinedAndRemovedOnlyWhenUnchanged(t *testing.T) {
+	for name, store := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			delta := crdt.NewList("a")
+			delta.Increment("milk")
+			err := store.AddHint(Hint{Email: "a@mail", IntendedServer: "s1", List: delta.ToGOB64(), IsDelta: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			full := crdt.NewList("b")
+			full.Increment("eggs")
+			err = store.AddHint(Hint{Email: "a@mail", IntendedServer: "s1", List: full.ToGOB64()})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hints, err := store.Hints()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hints) != 1 {
+				t.Fatalf("got %d hints, want 1", len(hints))
+			}
+			read := hints[0]
+			if read.IsDelta {
+				t.Error("a hint joined with a full state is still a delta")
+			}
+			if got, want := values(crdt.FromGOB64(read.List)), map[string]int{"milk": 1, "eggs": 1}; !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+
+			// updated after it was read, the hint is kept
+			more := crdt.NewList("c")
+			more.Increment("bread")
+			store.AddHint(Hint{Email: "a@mail", IntendedServer: "s1", List: more.ToGOB64(), IsDelta: true})
+			store.RemoveHint(read)
+			hints, _ = store.Hints()
+			if len(hints) != 1 {
+				t.Fatalf("changed hint was removed")
+			}
+
+			store.RemoveHint(hints[0])
+			hints, _ = store.Hints()
+			if len(hints) != 0 {
+				t.Errorf("got %d hints after removing, want 0", len(hints))
+			}
+		})
+	}
+}
+
+func TestConcurrentMergesLoseNoIncrements(t *testing.T) {
+	const writers, increments = 16, 25
+	for name, store := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			var wait sync.WaitGroup
+			for writer := 0; writer < writers; writer++ {
+				wait.Add(1)
+				go func(writer int) {
+					defer wait.Done()
+					for i := 0; i < increments; i++ {
+						// every increment comes from its own replica, so a lost merge loses it for good
+						list := crdt.NewList(fmt.Sprintf("w%d-%d", writer, i))
+						list.Increment("milk")
+						_, err := store.Merge("a@mail", "h1", list, false)
+						if err != nil {
+							t.Error(err)
+							return
+						}
+					}
+				}(writer)
+			}
+			wait.Wait()
+
+			stored, err := store.Get("h1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := stored.List.Data["milk"].Value(); got != writers*increments {
+				t.Errorf("got %d increments, want %d", got, writers*increments)
+			}
+			records, _ := store.Range("", "")
+			if len(records) != 1 {
+				t.Errorf("got %d stored lists, want 1", len(records))
+			}
+		})
+	}
+}
+
+func TestFileIsReplayedOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.log")
+	store, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	putItem(t, store, "a", "milk")
+	putItem(t, store, "a", "eggs")
+	putItem(t, store, "b", "milk")
+	store.Delete("b")
+	store.AddHint(Hint{Email: "c@mail", IntendedServer: "s1", List: crdt.NewList("c").ToGOB64()})
+	store.Close()
+
+	store, err = OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	records, _ := store.Range("", "")
+	if got, want := hashes(records), []string{"a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got, want := values(records[0].List), map[string]int{"milk": 1, "eggs": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	hints, _ := store.Hints()
+	if len(hints) != 1 {
+		t.Errorf("got %d hints, want 1", len(hints))
+	}
+}
+
+func TestFileCompactionKeepsTheLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.log")
+	store, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.compactAt = 4096
+	for i := 0; i < 200; i++ {
+		putItem(t, store, fmt.Sprintf("%c", 'a'+i%5), fmt.Sprintf("item%d", i%7))
+	}
+	store.Delete("e")
+	store.AddHint(Hint{Email: "f@mail", IntendedServer: "s1", List: crdt.NewList("f").ToGOB64()})
+	if store.compacted == 0 || store.size >= 2*store.compacted+4096 {
+		t.Fatalf("the log of %d bytes was not compacted", store.size)
+	}
+	before, _ := store.Range("", "")
+	store.Close()
+
+	store, err = OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	after, _ := store.Range("", "")
+	if got, want := hashes(after), []string{"a", "b", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range after {
+		if got, want := values(after[i].List), values(before[i].List); !reflect.DeepEqual(got, want) {
+			t.Errorf("list %s is %v after compaction, want %v", after[i].EmailHash, got, want)
+		}
+	}
+	hints, _ := store.Hints()
+	if len(hints) != 1 {
+		t.Errorf("got %d hints, want 1", len(hints))
+	}
+}
+
+func TestSQLiteMergesDuplicatedRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the table as it was before email_hash was unique
+	_, err = db.Exec("CREATE TABLE shopping_lists (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL, email_hash TEXT NOT NULL, shopping_list BLOB NOT NULL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range []string{"milk", "eggs"} {
+		list := crdt.NewList(item)
+		list.Increment(item)
+		_, err = db.Exec("INSERT INTO shopping_lists (email, email_hash, shopping_list) VALUES (?, ?, ?)", "a@mail", "h1", []byte(list.ToGOB64()))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	store, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	records, _ := store.Range("", "")
+	if len(records) != 1 {
+		t.Fatalf("got %d stored lists, want 1", len(records))
+	}
+	if got, want := values(records[0].List), map[string]int{"milk": 1, "eggs": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}